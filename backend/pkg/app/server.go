@@ -2,11 +2,14 @@ package app
 
 import (
 	"log"
+	"strings"
+	"time"
 
 	"woohoodsa/pkg/config"
 	"woohoodsa/pkg/database"
 	"woohoodsa/pkg/handlers"
 	"woohoodsa/pkg/middleware"
+	"woohoodsa/pkg/services"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -16,50 +19,173 @@ func SetupServer() *gin.Engine {
 	// Load configuration
 	config.LoadConfig()
 
+	if err := services.InitMailer(); err != nil {
+		log.Printf("Mailer misconfigured, logging mail instead: %v", err)
+	}
+	if err := middleware.InitRateLimitStore(); err != nil {
+		log.Printf("Rate limiting misconfigured, using in-memory buckets: %v", err)
+	}
+
 	// Connect to MongoDB
 	// Note: In serverless, we might need to handle connection pooling carefully
 	// But for now, standard connect is okay.
 	if err := database.Connect(); err != nil {
 		log.Printf("Failed to connect to MongoDB: %v", err)
+	} else if err := database.EnsureIndexes(); err != nil {
+		log.Printf("Failed to create MongoDB indexes: %v", err)
 	}
 
 	// Setup Gin router
 	r := gin.Default()
+	if len(config.AppConfig.TrustedProxies) > 0 {
+		if err := r.SetTrustedProxies(config.AppConfig.TrustedProxies); err != nil {
+			log.Printf("Invalid TRUSTED_PROXIES: %v", err)
+		}
+	}
 
 	// CORS configuration
 	r.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{"*"}, // Allow all for now, or restrict to Vercel URL later
+		// Credentialed requests (the OAuth link call sets a cookie) are only
+		// accepted from the frontend and any configured extra origins
+		AllowOrigins:     append([]string{strings.TrimRight(config.AppConfig.AppURL, "/")}, config.AppConfig.CORSOrigins...),
+		AllowWildcard:    true,
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
 		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
+		ExposeHeaders:    []string{"Retry-After", "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset"},
 		AllowCredentials: true,
 	}))
 
+	// Auth routes are limited per IP, and login also per username, so
+	// passwords can't be guessed quickly from one machine or many
+	authLimit := middleware.RateLimit("auth-ip", middleware.Limit{Requests: config.AppConfig.AuthRateLimitPerIP, Window: time.Minute}, middleware.ByIP)
+	loginLimit := middleware.RateLimit("login-user", middleware.Limit{Requests: config.AppConfig.LoginRateLimitPerUsername, Window: time.Minute}, middleware.ByJSONField("username"))
+	auth := r.Group("/api/auth")
+	auth.Use(authLimit)
+	{
+		auth.POST("/register", handlers.Register)
+		auth.POST("/login", loginLimit, handlers.Login)
+		auth.POST("/2fa", handlers.LoginTwoFactor)
+		auth.POST("/verify-email", handlers.VerifyEmail)
+		auth.POST("/forgot-password", handlers.ForgotPassword)
+		auth.POST("/reset-password", handlers.ResetPassword)
+	}
+
 	// Public routes
-	r.POST("/api/auth/register", handlers.Register)
-	r.POST("/api/auth/login", handlers.Login)
-	r.GET("/api/problems", handlers.GetProblems)
-	r.GET("/api/problems/:id", handlers.GetProblem)
+	r.POST("/api/auth/refresh", handlers.RefreshSession)
+	r.POST("/api/auth/logout", handlers.Logout)
+	r.GET("/api/auth/providers", handlers.GetOAuthProviders)
+	r.GET("/api/auth/oauth/:provider/start", handlers.StartOAuth)
+	r.GET("/api/auth/oauth/:provider/callback", handlers.OAuthCallback)
+	r.GET("/api/problems", middleware.OptionalAuthMiddleware(), handlers.GetProblems)
+	r.GET("/api/problems/random", middleware.OptionalAuthMiddleware(), handlers.GetRandomProblem)
+	r.GET("/api/problems/:id", middleware.OptionalAuthMiddleware(), handlers.GetProblem)
+	r.GET("/api/problems/by-slug/:slug", middleware.OptionalAuthMiddleware(), handlers.GetProblemBySlug)
 	r.GET("/api/topics", handlers.GetTopics)
+	r.GET("/api/topics/:topic/graph", middleware.OptionalAuthMiddleware(), handlers.GetTopicGraph)
+	r.GET("/api/daily", middleware.OptionalAuthMiddleware(), handlers.GetDailyChallenge)
+
+	// Study list routes
+	r.GET("/api/lists", middleware.OptionalAuthMiddleware(), handlers.GetStudyLists)
+	r.GET("/api/lists/:id", middleware.OptionalAuthMiddleware(), handlers.GetStudyList)
 
 	// Comment routes
 	r.GET("/api/comments/:problemId", handlers.GetComments)
 
+	// Public profiles
+	r.GET("/api/users/:username", middleware.OptionalAuthMiddleware(), handlers.GetPublicProfile)
+
+	// Per-user limits on routes that are expensive or easy to spam. Each can
+	// be tuned by name with RATE_LIMITS.
+	twoFactorLimit := middleware.RouteLimit("2fa", middleware.Limit{Requests: 5, Window: time.Minute})
+	listLimit := middleware.RouteLimit("lists", middleware.Limit{Requests: 10, Window: time.Minute})
+
+	// Routes personal access tokens may call, and the scope each needs
+	middleware.TokenRoutes = map[string]string{
+		"GET /api/profile":                "", // GetProfile hides secrets from token callers
+		"POST /api/submit":                middleware.ScopeSubmit,
+		"GET /api/submissions/:problemId": middleware.ScopeSubmit,
+		"GET /api/progress":               middleware.ScopeReadProgress,
+		"GET /api/progress/:problemId":    middleware.ScopeReadProgress,
+		"GET /api/recommendations":        middleware.ScopeReadProgress,
+	}
+
 	// Protected routes
 	protected := r.Group("/api")
 	protected.Use(middleware.AuthMiddleware())
 	{
 		protected.GET("/profile", handlers.GetProfile)
 		protected.PUT("/apikey", handlers.UpdateApiKey)
+		protected.PUT("/profile", handlers.UpdateProfile)
+		protected.PUT("/profile/locale", handlers.UpdateLocale)
+		protected.PUT("/profile/username", middleware.RouteLimit("username", middleware.Limit{Requests: 5, Window: time.Hour}), handlers.ChangeUsername)
+		protected.PUT("/email", middleware.RouteLimit("email", middleware.Limit{Requests: 5, Window: time.Hour}), handlers.UpdateEmail)
+		protected.PUT("/password", handlers.ChangePassword)
+		protected.POST("/2fa/enroll", handlers.EnrollTwoFactor)
+		protected.POST("/2fa/verify", twoFactorLimit, handlers.ConfirmTwoFactor)
+		protected.POST("/2fa/disable", twoFactorLimit, handlers.DisableTwoFactor)
+		protected.POST("/2fa/recovery-codes", twoFactorLimit, handlers.RegenerateRecoveryCodes)
+		protected.POST("/auth/oauth/:provider/link", handlers.LinkOAuth)
+		protected.DELETE("/auth/oauth/:provider", handlers.UnlinkOAuth)
+		protected.GET("/tokens", handlers.GetAccessTokens)
+		protected.POST("/tokens", handlers.CreateAccessToken)
+		protected.DELETE("/tokens/:id", handlers.RevokeAccessToken)
+		protected.GET("/account/export", middleware.RouteLimit("export", middleware.Limit{Requests: 5, Window: time.Hour}), handlers.ExportAccount)
+		protected.DELETE("/account", handlers.DeleteAccount)
+		protected.GET("/sessions", handlers.GetSessions)
+		protected.DELETE("/sessions/:id", handlers.RevokeSession)
 		protected.GET("/progress", handlers.GetProgress)
 		protected.GET("/progress/:problemId", handlers.GetProblemProgress)
 		protected.PUT("/progress/:problemId/notes", handlers.UpdateNotes)
-		protected.POST("/submit", handlers.SubmitCode)
+		protected.PUT("/progress/:problemId/bookmark", handlers.UpdateBookmark)
+		protected.POST("/problems/:id/reveal", middleware.RouteLimit("reveal", middleware.Limit{Requests: 30, Window: time.Minute}), handlers.RevealHint)
+		protected.GET("/problems/:id/editorial", handlers.GetEditorial)
+		protected.GET("/recommendations", handlers.GetRecommendations)
+		protected.POST("/submit", middleware.RouteLimit("submit", middleware.Limit{Requests: 10, Window: time.Minute}), handlers.SubmitCode)
 		protected.GET("/submissions/:problemId", handlers.GetSubmissions)
 
+		// Protected Study list routes
+		protected.POST("/lists", listLimit, handlers.CreateStudyList)
+		protected.PUT("/lists/:id", handlers.UpdateStudyList)
+		protected.DELETE("/lists/:id", handlers.DeleteStudyList)
+		protected.POST("/lists/:id/clone", listLimit, handlers.CloneStudyList)
+		protected.POST("/lists/:id/follow", handlers.FollowStudyList)
+		protected.DELETE("/lists/:id/follow", handlers.UnfollowStudyList)
+
 		// Protected Comment routes
-		protected.POST("/comments", handlers.CreateComment)
+		protected.POST("/comments", middleware.RouteLimit("comments", middleware.Limit{Requests: 5, Window: time.Minute}), handlers.CreateComment)
 		protected.DELETE("/comments/:id", handlers.DeleteComment)
 	}
 
+	// Problem content routes, open to instructors as well as admins
+	content := r.Group("/api/admin")
+	content.Use(middleware.AuthMiddleware(), middleware.RequirePermission(middleware.PermManageContent))
+	{
+		content.PUT("/problems/:id/slug", handlers.RenameProblemSlug)
+		content.PUT("/problems/:id/relations", handlers.UpdateProblemRelations)
+		content.PUT("/problems/:id/statement", handlers.UpdateProblemStatement)
+		content.GET("/problems/:id/translations", handlers.GetProblemTranslations)
+		content.PUT("/problems/:id/translations/:locale", handlers.UpsertProblemTranslation)
+		content.DELETE("/problems/:id/translations/:locale", handlers.DeleteProblemTranslation)
+		content.PUT("/problems/:id/editorial", handlers.UpsertEditorial)
+		content.DELETE("/problems/:id/editorial", handlers.DeleteEditorial)
+	}
+
+	// Admin routes
+	admin := r.Group("/api/admin")
+	admin.Use(middleware.AuthMiddleware(), middleware.AdminMiddleware())
+	{
+		admin.PUT("/users/:id/role", handlers.GrantRole)
+		admin.DELETE("/users/:id/role", handlers.RevokeRole)
+		admin.GET("/audit", handlers.GetAuditLog)
+		admin.POST("/problems/:id/rejudge", handlers.RejudgeProblem)
+		admin.GET("/daily", handlers.GetDailySchedule)
+		admin.PUT("/daily", handlers.ScheduleDailyChallenges)
+		admin.DELETE("/daily/:date", handlers.DeleteDailyChallenge)
+		admin.POST("/problems/:id/stats/recompute", handlers.RecomputeProblemStats)
+		admin.GET("/rejudge/:id", handlers.GetRejudgeJob)
+		admin.POST("/rejudge/:id/run", handlers.ContinueRejudgeJob)
+		admin.GET("/metrics", handlers.GetMetrics)
+	}
+
 	return r
 }
@@ -3,16 +3,102 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
+	// Must point at a replica set (a single-node one is fine locally):
+	// relation edits, username changes and account deletion run in
+	// transactions, which a standalone server rejects
 	MongoDBURI       string
 	DatabaseName     string
 	JWTSecret        string
 	OpenRouterAPIKey string
 	Port             string
+
+	// Access tokens are short-lived; refresh tokens keep a session alive and
+	// slide forward on every refresh
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+
+	// Public URL of the frontend, used to build links in emails and to send
+	// users back after an OAuth sign-in
+	AppURL string
+
+	// Public URL of this API, used for OAuth redirect URIs
+	APIURL string
+
+	// Origins besides AppURL allowed to call the API from a browser, e.g.
+	// preview deployments. "*" wildcards work: https://*.vercel.app
+	CORSOrigins []string
+
+	// Issuer shown next to the account in authenticator apps
+	TOTPIssuer string
+
+	// "memory" keeps rate limit buckets per process; "mongo" shares them
+	// between instances
+	RateLimitStore string
+
+	// Proxies whose X-Forwarded-For is believed when working out the client
+	// IP for rate limits. Unset trusts every proxy, which lets clients spoof
+	// their address, so production should set it.
+	TrustedProxies []string
+
+	// Requests per minute to the auth routes from one IP, and login attempts
+	// per minute for one username
+	AuthRateLimitPerIP        int
+	LoginRateLimitPerUsername int
+
+	// Per-route limits overriding the defaults declared in SetupServer, from
+	// RATE_LIMITS="name=requests/window,..."
+	RateLimitOverrides map[string]string
+
+	// Failed logins in a row before an account is locked; each further
+	// failure doubles the lockout
+	LoginLockoutThreshold int
+
+	// Sign-in providers keyed by the name used in /api/auth/oauth/:provider.
+	// Only providers with a client ID are enabled.
+	OAuthProviders map[string]OAuthProvider
+
+	// "smtp" sends real mail; "log" writes messages to MailLogPath, or the
+	// server log when that is empty, for local development
+	MailDriver   string
+	MailFrom     string
+	MailLogPath  string
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+
+	// Failed attempts required before the solution can be revealed; 0 disables the gate
+	SolutionUnlockAttempts int
+
+	// IANA zone that decides when the daily challenge rolls over
+	DailyTimezone string
+
+	// Locales problems can be translated into; the first is the default and
+	// is what the untranslated problem fields are written in
+	SupportedLocales []string
+}
+
+// OAuthProvider configures an OAuth2 authorization-code flow. Kind selects
+// how the user's identity is read once a token is issued: "github" uses the
+// GitHub REST API at APIURL, "oidc" calls the standard UserInfoURL. The URLs
+// are overridable so the flow can run against a local stub provider.
+type OAuthProvider struct {
+	Kind         string
+	ClientID     string
+	ClientSecret string
+	AuthURL      string
+	TokenURL     string
+	UserInfoURL  string
+	APIURL       string
+	Scopes       []string
 }
 
 var AppConfig *Config
@@ -34,6 +120,37 @@ func LoadConfig() {
 		JWTSecret:        getEnv("JWT_SECRET", "default-secret-key"),
 		OpenRouterAPIKey: getEnv("OPENROUTER_API_KEY", ""),
 		Port:             getEnv("PORT", "8080"),
+
+		AccessTokenTTL:  time.Duration(getEnvInt("ACCESS_TOKEN_MINUTES", 15)) * time.Minute,
+		RefreshTokenTTL: time.Duration(getEnvInt("REFRESH_TOKEN_DAYS", 30)) * 24 * time.Hour,
+
+		AppURL: getEnv("APP_URL", "http://localhost:3000"),
+		APIURL: getEnv("API_URL", "http://localhost:8080"),
+
+		CORSOrigins: getEnvList("CORS_ORIGINS", nil),
+
+		TOTPIssuer: getEnv("TOTP_ISSUER", "Woohoo DSA"),
+
+		RateLimitStore:            getEnv("RATE_LIMIT_STORE", "memory"),
+		TrustedProxies:            getEnvList("TRUSTED_PROXIES", nil),
+		AuthRateLimitPerIP:        getEnvInt("AUTH_RATE_LIMIT_PER_IP", 20),
+		LoginRateLimitPerUsername: getEnvInt("LOGIN_RATE_LIMIT_PER_USERNAME", 5),
+		LoginLockoutThreshold:     getEnvInt("LOGIN_LOCKOUT_THRESHOLD", 5),
+		RateLimitOverrides:        getEnvMap("RATE_LIMITS"),
+
+		OAuthProviders: oauthProviders(),
+
+		MailDriver:   getEnv("MAIL_DRIVER", "log"),
+		MailFrom:     getEnv("MAIL_FROM", "Woohoo DSA <no-reply@localhost>"),
+		MailLogPath:  getEnv("MAIL_LOG_PATH", ""),
+		SMTPHost:     getEnv("SMTP_HOST", ""),
+		SMTPPort:     getEnvInt("SMTP_PORT", 587),
+		SMTPUsername: getEnv("SMTP_USERNAME", ""),
+		SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+
+		SolutionUnlockAttempts: getEnvInt("SOLUTION_UNLOCK_ATTEMPTS", 0),
+		DailyTimezone:          getEnv("DAILY_TIMEZONE", "UTC"),
+		SupportedLocales:       getEnvList("SUPPORTED_LOCALES", []string{"en", "hi", "es"}),
 	}
 }
 
@@ -43,3 +160,75 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getEnvInt(key string, defaultValue int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvList(key string, defaultValue []string) []string {
+	var values []string
+	for _, v := range strings.Split(os.Getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	if len(values) == 0 {
+		return defaultValue
+	}
+	return values
+}
+
+// getEnvMap reads comma-separated key=value pairs.
+func getEnvMap(key string) map[string]string {
+	values := map[string]string{}
+	for _, pair := range getEnvList(key, nil) {
+		if k, v, ok := strings.Cut(pair, "="); ok {
+			values[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+	}
+	return values
+}
+
+func oauthProviders() map[string]OAuthProvider {
+	all := map[string]OAuthProvider{
+		"github": {
+			Kind:         "github",
+			ClientID:     getEnv("GITHUB_CLIENT_ID", ""),
+			ClientSecret: getEnv("GITHUB_CLIENT_SECRET", ""),
+			AuthURL:      getEnv("GITHUB_AUTH_URL", "https://github.com/login/oauth/authorize"),
+			TokenURL:     getEnv("GITHUB_TOKEN_URL", "https://github.com/login/oauth/access_token"),
+			APIURL:       getEnv("GITHUB_API_URL", "https://api.github.com"),
+			Scopes:       []string{"read:user", "user:email"},
+		},
+		"google": {
+			Kind:         "oidc",
+			ClientID:     getEnv("GOOGLE_CLIENT_ID", ""),
+			ClientSecret: getEnv("GOOGLE_CLIENT_SECRET", ""),
+			AuthURL:      getEnv("GOOGLE_AUTH_URL", "https://accounts.google.com/o/oauth2/v2/auth"),
+			TokenURL:     getEnv("GOOGLE_TOKEN_URL", "https://oauth2.googleapis.com/token"),
+			UserInfoURL:  getEnv("GOOGLE_USERINFO_URL", "https://openidconnect.googleapis.com/v1/userinfo"),
+			Scopes:       []string{"openid", "email", "profile"},
+		},
+		// Any other OpenID Connect provider, e.g. a company SSO or a local stub
+		"oidc": {
+			Kind:         "oidc",
+			ClientID:     getEnv("OIDC_CLIENT_ID", ""),
+			ClientSecret: getEnv("OIDC_CLIENT_SECRET", ""),
+			AuthURL:      getEnv("OIDC_AUTH_URL", ""),
+			TokenURL:     getEnv("OIDC_TOKEN_URL", ""),
+			UserInfoURL:  getEnv("OIDC_USERINFO_URL", ""),
+			Scopes:       []string{"openid", "email", "profile"},
+		},
+	}
+
+	enabled := map[string]OAuthProvider{}
+	for name, provider := range all {
+		if provider.ClientID != "" {
+			enabled[name] = provider
+		}
+	}
+	return enabled
+}
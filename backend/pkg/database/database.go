@@ -8,6 +8,7 @@ import (
 
 	"woohoodsa/pkg/config"
 
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -40,6 +41,17 @@ func Connect() error {
 		return err
 	}
 
+	// Transactions need a replica set or sharded cluster. Say so now rather
+	// than on the first relation edit, username change or account deletion.
+	var hello struct {
+		SetName string `bson:"setName"`
+		Msg     string `bson:"msg"`
+	}
+	if err := client.Database("admin").RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello); err == nil &&
+		hello.SetName == "" && hello.Msg != "isdbgrid" {
+		log.Println("MongoDB is a standalone server, so transactions will fail; run it as a replica set (see MONGODB_URI)")
+	}
+
 	Client = client
 	DB = client.Database(config.AppConfig.DatabaseName)
 	log.Println("✓ Connected to MongoDB Atlas!")
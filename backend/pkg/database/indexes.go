@@ -0,0 +1,189 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// EnsureIndexes creates the indexes the handlers rely on. CreateMany is a
+// no-op for indexes that already exist, so it is safe to run on every cold start.
+func EnsureIndexes() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	_, err := DB.Collection("problems").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			// Backs the `q` search on GET /api/problems
+			Keys: bson.D{
+				{Key: "title", Value: "text"},
+				{Key: "description", Value: "text"},
+			},
+			Options: options.Index().
+				SetName("problems_text").
+				SetWeights(bson.M{"title": 10, "description": 1}),
+		},
+		{
+			// Problems without a slug yet are exempt from uniqueness
+			Keys: bson.D{{Key: "slug", Value: 1}},
+			Options: options.Index().
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"slug": bson.M{"$gt": ""}}),
+		},
+		{Keys: bson.D{{Key: "previous_slugs", Value: 1}}},
+		{Keys: bson.D{{Key: "tags", Value: 1}}},
+		{Keys: bson.D{{Key: "companies", Value: 1}}},
+		{Keys: bson.D{{Key: "prerequisites", Value: 1}}},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = DB.Collection("editorials").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "problem_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = DB.Collection("study_lists").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "owner_id", Value: 1}}},
+		{Keys: bson.D{{Key: "visibility", Value: 1}, {Key: "created_at", Value: -1}}},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = DB.Collection("list_follows").Indexes().CreateOne(ctx, mongo.IndexModel{
+		// Following twice is reported as a duplicate key by FollowStudyList
+		Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "list_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = DB.Collection("daily_challenges").Indexes().CreateOne(ctx, mongo.IndexModel{
+		// One challenge per day; also settles races between auto-picks
+		Keys:    bson.D{{Key: "date", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = DB.Collection("users").Indexes().CreateOne(ctx, mongo.IndexModel{
+		// Email is optional, so only documents that have one are indexed
+		Keys: bson.D{{Key: "email", Value: 1}},
+		Options: options.Index().SetUnique(true).
+			SetPartialFilterExpression(bson.M{"email": bson.M{"$gt": ""}}),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = DB.Collection("users").Indexes().CreateOne(ctx, mongo.IndexModel{
+		// A provider account can be linked to one user only
+		Keys: bson.D{{Key: "identities.provider", Value: 1}, {Key: "identities.subject", Value: 1}},
+		Options: options.Index().SetUnique(true).
+			SetPartialFilterExpression(bson.M{"identities.subject": bson.M{"$exists": true}}),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = DB.Collection("oauth_states").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "state_hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = DB.Collection("user_tokens").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "token_hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "purpose", Value: 1}}},
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = DB.Collection("access_tokens").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "token_hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = DB.Collection("audit_log").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "action", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "actor_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "target_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Rate limit buckets and failed-login streaks are keyed by _id and only
+	// need expiring
+	for _, name := range []string{"rate_limits", "login_failures"} {
+		_, err = DB.Collection(name).Indexes().CreateOne(ctx, mongo.IndexModel{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = DB.Collection("sessions").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "token_hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "previous_hashes", Value: 1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "last_used_at", Value: -1}}},
+		// Mongo drops sessions once their refresh token can no longer be used
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = DB.Collection("progress").Indexes().CreateOne(ctx, mongo.IndexModel{
+		// Per-user lookups, including the join in GET /api/problems
+		Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "problem_id", Value: 1}},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = DB.Collection("comments").Indexes().CreateOne(ctx, mongo.IndexModel{
+		// Renames rewrite the username on each of the user's comments
+		Keys: bson.D{{Key: "user_id", Value: 1}},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = DB.Collection("users").Indexes().CreateOne(ctx, mongo.IndexModel{
+		// Old usernames redirect to the current one and stay held for a while
+		Keys: bson.D{{Key: "username_history.username", Value: 1}},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Kept last: a database that already holds duplicate usernames fails
+	// here, and every other index should exist by then. Rename the duplicates
+	// and restart to create it.
+	_, err = DB.Collection("users").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "username", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"woohoodsa/pkg/database"
+	"woohoodsa/pkg/middleware"
+	"woohoodsa/pkg/models"
+	"woohoodsa/pkg/services"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const maxAccessTokens = 20
+
+// CreateAccessToken issues a personal access token. The token is in the
+// response once and can't be retrieved again.
+func CreateAccessToken(c *gin.Context) {
+	userObjID, _ := primitive.ObjectIDFromHex(c.GetString("userID"))
+
+	var req models.CreateAccessTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	scopes := []string{}
+	for _, scope := range req.Scopes {
+		if !slices.Contains(middleware.AccessTokenScopes, scope) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown scope: " + scope, "scopes": middleware.AccessTokenScopes})
+			return
+		}
+		if !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	collection := database.GetCollection("access_tokens")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	count, err := collection.CountDocuments(ctx, bson.M{"user_id": userObjID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create token"})
+		return
+	}
+	if count >= maxAccessTokens {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Token limit reached; revoke one you no longer use"})
+		return
+	}
+
+	secret, hash, err := services.NewOpaqueToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create token"})
+		return
+	}
+	token := middleware.AccessTokenPrefix + secret
+
+	now := time.Now()
+	accessToken := models.AccessToken{
+		ID:        primitive.NewObjectID(),
+		UserID:    userObjID,
+		Name:      strings.TrimSpace(req.Name),
+		Scopes:    scopes,
+		TokenHash: hash,
+		Prefix:    token[:len(middleware.AccessTokenPrefix)+4],
+		CreatedAt: now,
+	}
+	if req.ExpiresInDays > 0 {
+		expiresAt := now.AddDate(0, 0, req.ExpiresInDays)
+		accessToken.ExpiresAt = &expiresAt
+	}
+
+	if _, err := collection.InsertOne(ctx, accessToken); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create token"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.CreateAccessTokenResponse{AccessToken: accessToken, Token: token})
+}
+
+func GetAccessTokens(c *gin.Context) {
+	userObjID, _ := primitive.ObjectIDFromHex(c.GetString("userID"))
+
+	collection := database.GetCollection("access_tokens")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := collection.Find(ctx, bson.M{"user_id": userObjID}, options.Find().SetSort(bson.M{"created_at": -1}))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tokens"})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	tokens := []models.AccessToken{}
+	if err := cursor.All(ctx, &tokens); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse tokens"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+func RevokeAccessToken(c *gin.Context) {
+	userObjID, _ := primitive.ObjectIDFromHex(c.GetString("userID"))
+	tokenObjID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid token ID"})
+		return
+	}
+
+	collection := database.GetCollection("access_tokens")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, err := collection.DeleteOne(ctx, bson.M{"_id": tokenObjID, "user_id": userObjID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke token"})
+		return
+	}
+	if result.DeletedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Token not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Token revoked"})
+}
@@ -0,0 +1,297 @@
+package handlers
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"woohoodsa/pkg/database"
+	"woohoodsa/pkg/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var errInvalidSecondFactor = errors.New("invalid second factor")
+
+// ExportAccount downloads everything stored about the caller, as a zip of
+// JSON files by default or a single JSON document with ?format=json.
+func ExportAccount(c *gin.Context) {
+	userObjID, _ := primitive.ObjectIDFromHex(c.GetString("userID"))
+	format := c.DefaultQuery("format", "zip")
+	if format != "zip" && format != "json" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be zip or json"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	export, err := buildAccountExport(ctx, userObjID)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export account"})
+		return
+	}
+
+	filename := "woohoodsa-export-" + export.ExportedAt.Format("2006-01-02")
+	if format == "json" {
+		c.Header("Content-Disposition", `attachment; filename="`+filename+`.json"`)
+		c.JSON(http.StatusOK, export)
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`.zip"`)
+	c.Header("Content-Type", "application/zip")
+	c.Status(http.StatusOK)
+
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", export.Profile},
+		{"progress.json", export.Progress},
+		{"notes.json", export.Notes},
+		{"submissions.json", export.Submissions},
+		{"comments.json", export.Comments},
+		{"study_lists.json", export.StudyLists},
+		{"followed_lists.json", export.FollowedLists},
+		{"sessions.json", export.Sessions},
+		{"access_tokens.json", export.AccessTokens},
+	}
+	archive := zip.NewWriter(c.Writer)
+	for _, file := range files {
+		w, err := archive.CreateHeader(&zip.FileHeader{Name: file.name, Method: zip.Deflate, Modified: export.ExportedAt})
+		if err == nil {
+			encoder := json.NewEncoder(w)
+			encoder.SetIndent("", "  ")
+			err = encoder.Encode(file.data)
+		}
+		if err != nil {
+			// Headers are already sent; all we can do is cut the archive short
+			log.Printf("Failed to write account export for user %s: %v", userObjID.Hex(), err)
+			return
+		}
+	}
+	if err := archive.Close(); err != nil {
+		log.Printf("Failed to finish account export for user %s: %v", userObjID.Hex(), err)
+	}
+}
+
+// DeleteAccount erases the caller's account in one transaction. Personal
+// data is deleted outright; submissions are kept without their owner or code
+// so problem statistics stay correct. The audit log is left as it is.
+func DeleteAccount(c *gin.Context) {
+	userObjID, _ := primitive.ObjectIDFromHex(c.GetString("userID"))
+
+	var req models.DeleteAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var user models.User
+	if err := database.GetCollection("users").FindOne(ctx, bson.M{"_id": userObjID}).Decode(&user); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if user.PasswordHash != "" {
+		if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)) != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Password is incorrect"})
+			return
+		}
+	} else if req.Confirm != user.Username {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Type your username to confirm"})
+		return
+	}
+	session, err := database.Client.StartSession()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete account"})
+		return
+	}
+	defer session.EndSession(ctx)
+
+	// The second factor is checked inside the transaction, so a code or
+	// recovery code is only used up if the account is actually erased
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		if twoFactorEnabled(user) {
+			ok, err := verifySecondFactor(sc, user, req.Code, true)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				return nil, errInvalidSecondFactor
+			}
+		}
+		return nil, eraseUser(sc, user)
+	})
+	if errors.Is(err, errInvalidSecondFactor) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid code"})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to delete account %s: %v", userObjID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete account"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Account deleted"})
+}
+
+func buildAccountExport(ctx context.Context, userID primitive.ObjectID) (models.AccountExport, error) {
+	export := models.AccountExport{ExportedAt: time.Now().UTC()}
+	if err := database.GetCollection("users").FindOne(ctx, bson.M{"_id": userID}).Decode(&export.Profile); err != nil {
+		return export, err
+	}
+
+	byUser := bson.M{"user_id": userID}
+	var err error
+	if export.Progress, err = findAllDocuments[models.Progress](ctx, "progress", byUser); err != nil {
+		return export, err
+	}
+	if export.Submissions, err = findAllDocuments[models.Submission](ctx, "submissions", byUser); err != nil {
+		return export, err
+	}
+	if export.Comments, err = findAllDocuments[models.Comment](ctx, "comments", byUser); err != nil {
+		return export, err
+	}
+	if export.StudyLists, err = findAllDocuments[models.StudyList](ctx, "study_lists", bson.M{"owner_id": userID}); err != nil {
+		return export, err
+	}
+	if export.FollowedLists, err = findAllDocuments[models.ListFollow](ctx, "list_follows", byUser); err != nil {
+		return export, err
+	}
+	if export.Sessions, err = findAllDocuments[models.Session](ctx, "sessions", byUser); err != nil {
+		return export, err
+	}
+	if export.AccessTokens, err = findAllDocuments[models.AccessToken](ctx, "access_tokens", byUser); err != nil {
+		return export, err
+	}
+
+	var noted []primitive.ObjectID
+	for _, p := range export.Progress {
+		if strings.TrimSpace(p.Notes) != "" {
+			noted = append(noted, p.ProblemID)
+		}
+	}
+	titles := map[primitive.ObjectID]string{}
+	if len(noted) > 0 {
+		problems, err := findAllDocuments[models.Problem](ctx, "problems", bson.M{"_id": bson.M{"$in": noted}},
+			options.Find().SetProjection(bson.M{"title": 1}))
+		if err != nil {
+			return export, err
+		}
+		for _, p := range problems {
+			titles[p.ID] = p.Title
+		}
+	}
+	export.Notes = []models.ExportNote{}
+	for _, p := range export.Progress {
+		if strings.TrimSpace(p.Notes) != "" {
+			export.Notes = append(export.Notes, models.ExportNote{
+				ProblemID:    p.ProblemID.Hex(),
+				ProblemTitle: titles[p.ProblemID],
+				Notes:        p.Notes,
+				UpdatedAt:    p.UpdatedAt,
+			})
+		}
+	}
+	return export, nil
+}
+
+// eraseUser removes or anonymizes every document belonging to user. It is
+// meant to run inside a transaction.
+func eraseUser(ctx context.Context, user models.User) error {
+	byUser := bson.M{"user_id": user.ID}
+
+	// Lists the user followed lose a follower; lists they owned go, along
+	// with everyone's follows of them
+	follows, err := findAllDocuments[models.ListFollow](ctx, "list_follows", byUser)
+	if err != nil {
+		return err
+	}
+	followed := make([]primitive.ObjectID, 0, len(follows))
+	for _, f := range follows {
+		followed = append(followed, f.ListID)
+	}
+	if len(followed) > 0 {
+		_, err = database.GetCollection("study_lists").UpdateMany(ctx,
+			bson.M{"_id": bson.M{"$in": followed}, "owner_id": bson.M{"$ne": user.ID}},
+			bson.M{"$inc": bson.M{"followers": -1}})
+		if err != nil {
+			return err
+		}
+	}
+	owned, err := findAllDocuments[models.StudyList](ctx, "study_lists", bson.M{"owner_id": user.ID},
+		options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return err
+	}
+	ownedIDs := make([]primitive.ObjectID, 0, len(owned))
+	for _, l := range owned {
+		ownedIDs = append(ownedIDs, l.ID)
+	}
+	_, err = database.GetCollection("list_follows").DeleteMany(ctx, bson.M{"$or": bson.A{
+		byUser,
+		bson.M{"list_id": bson.M{"$in": ownedIDs}},
+	}})
+	if err != nil {
+		return err
+	}
+	if _, err := database.GetCollection("study_lists").DeleteMany(ctx, bson.M{"owner_id": user.ID}); err != nil {
+		return err
+	}
+
+	// Submissions feed problem statistics, so they stay without an owner
+	_, err = database.GetCollection("submissions").UpdateMany(ctx, byUser, bson.M{
+		"$set": bson.M{"user_id": primitive.NilObjectID, "code": "", "feedback": ""},
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, name := range []string{"progress", "comments", "sessions", "user_tokens", "access_tokens"} {
+		if _, err := database.GetCollection(name).DeleteMany(ctx, byUser); err != nil {
+			return err
+		}
+	}
+	if _, err := database.GetCollection("oauth_states").DeleteMany(ctx, bson.M{"link_user_id": user.ID}); err != nil {
+		return err
+	}
+	if _, err := database.GetCollection("login_failures").DeleteOne(ctx, bson.M{"_id": lockoutKey(user.Username)}); err != nil {
+		return err
+	}
+
+	_, err = database.GetCollection("users").DeleteOne(ctx, bson.M{"_id": user.ID})
+	return err
+}
+
+func findAllDocuments[T any](ctx context.Context, collection string, filter bson.M, opts ...*options.FindOptions) ([]T, error) {
+	cursor, err := database.GetCollection(collection).Find(ctx, filter, opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	docs := []T{}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
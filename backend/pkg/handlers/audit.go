@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"woohoodsa/pkg/database"
+	"woohoodsa/pkg/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GetAuditLog lists privileged actions, newest first. ?action=, ?actor= and
+// ?target= narrow it down.
+func GetAuditLog(c *gin.Context) {
+	params, err := parseListParams(c, auditSorts, "newest", 50)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	filter := bson.M{}
+	if action := c.Query("action"); action != "" {
+		filter["action"] = action
+	}
+	for query, field := range map[string]string{"actor": "actor_id", "target": "target_id"} {
+		if raw := c.Query(query); raw != "" {
+			id, err := primitive.ObjectIDFromHex(raw)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + query + " ID"})
+				return
+			}
+			filter[field] = id
+		}
+	}
+
+	collection := database.GetCollection("audit_log")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch audit log"})
+		return
+	}
+
+	cursor, err := collection.Find(ctx, params.withSeek(filter), params.findOptions())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch audit log"})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	entries, next, err := collectPage[models.AuditEntry](ctx, cursor, params)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse audit log"})
+		return
+	}
+
+	writeList(c, models.ListResponse[models.AuditEntry]{
+		Items: entries,
+		Total: total,
+		Next:  next,
+	})
+}
+
+var auditSorts = map[string]bson.D{
+	"newest": {{Key: "created_at", Value: -1}},
+	"oldest": {{Key: "created_at", Value: 1}},
+}
+
+// recordAudit notes a privileged action by the caller. The action has
+// already happened, so a failure to record it is logged, not returned.
+func recordAudit(ctx context.Context, c *gin.Context, action, targetType string, targetID primitive.ObjectID, details map[string]string) {
+	actorID, _ := primitive.ObjectIDFromHex(c.GetString("userID"))
+	_, err := database.GetCollection("audit_log").InsertOne(ctx, models.AuditEntry{
+		ActorID:       actorID,
+		ActorUsername: c.GetString("username"),
+		Action:        action,
+		TargetType:    targetType,
+		TargetID:      targetID,
+		Details:       details,
+		CreatedAt:     time.Now(),
+	})
+	if err != nil {
+		log.Printf("Failed to record audit entry %s on %s %s: %v", action, targetType, targetID.Hex(), err)
+	}
+}
@@ -2,16 +2,19 @@ package handlers
 
 import (
 	"context"
+	"log"
 	"net/http"
 	"time"
 
+	"woohoodsa/pkg/config"
 	"woohoodsa/pkg/database"
-	"woohoodsa/pkg/middleware"
 	"woohoodsa/pkg/models"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -21,15 +24,23 @@ func Register(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if !usernamePattern.MatchString(req.Username) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Username may only contain letters, digits, '.', '_' and '-'"})
+		return
+	}
 
 	collection := database.GetCollection("users")
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	// Check if username already exists
-	var existingUser models.User
-	err := collection.FindOne(ctx, bson.M{"username": req.Username}).Decode(&existingUser)
-	if err == nil {
+	// Check if username already exists or is still held for someone who
+	// changed away from it
+	taken, err := usernameTaken(ctx, req.Username, primitive.NilObjectID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check username"})
+		return
+	}
+	if taken {
 		c.JSON(http.StatusConflict, gin.H{"error": "Username already taken"})
 		return
 	}
@@ -46,6 +57,7 @@ func Register(c *gin.Context) {
 		ID:           primitive.NewObjectID(),
 		Username:     req.Username,
 		PasswordHash: string(hashedPassword),
+		Email:        normalizeEmail(req.Email),
 		ApiKey:       req.ApiKey,
 		TrialUsage:   0,
 		SolvedCount:  0,
@@ -53,22 +65,36 @@ func Register(c *gin.Context) {
 	}
 
 	_, err = collection.InsertOne(ctx, user)
+	if duplicateKeyOn(err, "username_1") {
+		// Someone registered the same name since the check above
+		c.JSON(http.StatusConflict, gin.H{"error": "Username already taken"})
+		return
+	}
+	if mongo.IsDuplicateKeyError(err) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Email already in use"})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
 		return
 	}
 
+	// The account works without a verified email, so a mail failure only
+	// means the user has to ask for another link
+	if user.Email != "" {
+		if err := sendVerificationEmail(ctx, user); err != nil {
+			log.Printf("Failed to send verification email to user %s: %v", user.ID.Hex(), err)
+		}
+	}
+
 	// Generate token
-	token, err := middleware.GenerateToken(user.ID.Hex(), user.Username)
+	response, err := startSession(ctx, c, user)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
 		return
 	}
 
-	c.JSON(http.StatusCreated, models.AuthResponse{
-		Token: token,
-		User:  user,
-	})
+	c.JSON(http.StatusCreated, response)
 }
 
 func Login(c *gin.Context) {
@@ -82,9 +108,16 @@ func Login(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
+	// Locked usernames are refused before bcrypt runs
+	if remaining := loginLockedFor(ctx, req.Username); remaining > 0 {
+		lockedOut(c, remaining)
+		return
+	}
+
 	var user models.User
 	err := collection.FindOne(ctx, bson.M{"username": req.Username}).Decode(&user)
 	if err != nil {
+		recordLoginFailure(ctx, req.Username)
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
 		return
 	}
@@ -92,21 +125,31 @@ func Login(c *gin.Context) {
 	// Verify password
 	err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password))
 	if err != nil {
+		recordLoginFailure(ctx, req.Username)
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
 		return
 	}
 
+	// With 2FA on, the password only earns a challenge for the code step
+	if twoFactorEnabled(user) {
+		challenge, err := twoFactorChallenge(ctx, user)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start two-factor sign-in"})
+			return
+		}
+		c.JSON(http.StatusOK, challenge)
+		return
+	}
+	clearLoginFailures(ctx, req.Username)
+
 	// Generate token
-	token, err := middleware.GenerateToken(user.ID.Hex(), user.Username)
+	response, err := startSession(ctx, c, user)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
 		return
 	}
 
-	c.JSON(http.StatusOK, models.AuthResponse{
-		Token: token,
-		User:  user,
-	})
+	c.JSON(http.StatusOK, response)
 }
 
 func GetProfile(c *gin.Context) {
@@ -121,8 +164,15 @@ func GetProfile(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
+	// Personal access tokens only get the public parts of the profile; a
+	// leaked script token must not hand out the API key or account secrets
+	opts := options.FindOne()
+	if c.GetString("accessTokenID") != "" {
+		opts.SetProjection(bson.M{"api_key": 0, "email": 0, "identities": 0, "two_factor": 0, "username_history": 0})
+	}
+
 	var user models.User
-	err = collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&user)
+	err = collection.FindOne(ctx, bson.M{"_id": objectID}, opts).Decode(&user)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
@@ -161,3 +211,38 @@ func UpdateApiKey(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "API key updated successfully"})
 }
+
+func UpdateLocale(c *gin.Context) {
+	userID := c.GetString("userID")
+	objectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var req models.UpdateLocaleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Locale != "" && !isSupportedLocale(req.Locale) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported locale: " + req.Locale, "supported": config.AppConfig.SupportedLocales})
+		return
+	}
+
+	collection := database.GetCollection("users")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	update := bson.M{"$set": bson.M{"locale": req.Locale}}
+	if req.Locale == "" {
+		update = bson.M{"$unset": bson.M{"locale": ""}}
+	}
+	_, err = collection.UpdateOne(ctx, bson.M{"_id": objectID}, update)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update locale"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Locale updated successfully", "locale": req.Locale})
+}
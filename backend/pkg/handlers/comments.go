@@ -6,19 +6,26 @@ import (
 	"time"
 
 	"woohoodsa/pkg/database"
+	"woohoodsa/pkg/middleware"
 	"woohoodsa/pkg/models"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 func GetComments(c *gin.Context) {
 	problemID := c.Param("problemId")
-	problemObjID, err := primitive.ObjectIDFromHex(problemID)
+	problemObjID, err := resolveProblemID(problemID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid problem ID"})
+		problemLookupFailed(c, err)
+		return
+	}
+
+	params, err := parseListParams(c, commentSorts, "newest", 20)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
@@ -26,32 +33,42 @@ func GetComments(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	opts := options.Find().SetSort(bson.M{"created_at": -1})
-	cursor, err := collection.Find(ctx, bson.M{"problem_id": problemObjID}, opts)
+	filter := bson.M{"problem_id": problemObjID}
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch comments"})
+		return
+	}
+
+	cursor, err := collection.Find(ctx, params.withSeek(filter), params.findOptions())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch comments"})
 		return
 	}
 	defer cursor.Close(ctx)
 
-	var comments []models.Comment
-	if err := cursor.All(ctx, &comments); err != nil {
+	comments, next, err := collectPage[models.Comment](ctx, cursor, params)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse comments"})
 		return
 	}
 
-	// Initialize empty slice if nil to return [] instead of null
-	if comments == nil {
-		comments = []models.Comment{}
-	}
+	writeList(c, models.ListResponse[models.Comment]{
+		Items: comments,
+		Total: total,
+		Next:  next,
+	})
+}
 
-	c.JSON(http.StatusOK, comments)
+var commentSorts = map[string]bson.D{
+	"newest": {{Key: "created_at", Value: -1}},
+	"oldest": {{Key: "created_at", Value: 1}},
+	"likes":  {{Key: "likes", Value: -1}, {Key: "created_at", Value: -1}},
 }
 
 func CreateComment(c *gin.Context) {
 	userID := c.GetString("userID")
 	userObjID, _ := primitive.ObjectIDFromHex(userID)
-	username := c.GetString("username")
 
 	var req models.CreateCommentRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -59,9 +76,19 @@ func CreateComment(c *gin.Context) {
 		return
 	}
 
-	problemObjID, err := primitive.ObjectIDFromHex(req.ProblemID)
+	problemObjID, err := resolveProblemID(req.ProblemID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid problem ID"})
+		problemLookupFailed(c, err)
+		return
+	}
+
+	collection := database.GetCollection("comments")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	username, err := currentUsername(ctx, userObjID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
 	}
 
@@ -75,10 +102,6 @@ func CreateComment(c *gin.Context) {
 		CreatedAt: time.Now(),
 	}
 
-	collection := database.GetCollection("comments")
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
 	_, err = collection.InsertOne(ctx, comment)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create comment"})
@@ -103,20 +126,30 @@ func DeleteComment(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	// Only allow user to delete their own comment
-	result, err := collection.DeleteOne(ctx, bson.M{
-		"_id":     commentObjID,
-		"user_id": userObjID,
-	})
+	// Users delete their own comments; moderators can delete anyone's
+	filter := bson.M{"_id": commentObjID, "user_id": userObjID}
+	moderating := middleware.Can(c, middleware.PermModerateComments)
+	if moderating {
+		delete(filter, "user_id")
+	}
 
+	var comment models.Comment
+	err = collection.FindOneAndDelete(ctx, filter).Decode(&comment)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Comment not found or unauthorized"})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete comment"})
 		return
 	}
 
-	if result.DeletedCount == 0 {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Comment not found or unauthorized"})
-		return
+	if moderating && comment.UserID != userObjID {
+		recordAudit(ctx, c, "comment.delete", "comment", comment.ID, map[string]string{
+			"author":    comment.Username,
+			"problemId": comment.ProblemID.Hex(),
+			"content":   comment.Content,
+		})
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Comment deleted"})
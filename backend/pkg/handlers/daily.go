@@ -0,0 +1,324 @@
+package handlers
+
+import (
+	"context"
+	"hash/fnv"
+	"log"
+	"math/rand"
+	"net/http"
+	"sort"
+	"time"
+	_ "time/tzdata" // Serverless images don't always ship a zoneinfo database
+
+	"woohoodsa/pkg/config"
+	"woohoodsa/pkg/database"
+	"woohoodsa/pkg/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const dateLayout = "2006-01-02"
+
+// dailyRecentDays is how far back the automatic picker looks to avoid
+// featuring the same problem twice in a short span.
+const dailyRecentDays = 30
+
+// dailyDifficultyWeights skews automatic picks towards Medium problems.
+var dailyDifficultyWeights = map[string]int{
+	"Easy":   3,
+	"Medium": 5,
+	"Hard":   2,
+}
+
+func GetDailyChallenge(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	today := dailyDate(time.Now())
+	daily, err := dailyChallengeFor(ctx, today)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to pick daily challenge"})
+		return
+	}
+
+	var problem models.Problem
+	err = database.GetCollection("problems").FindOne(ctx, bson.M{"_id": daily.ProblemID}, problemDetailOptions()).Decode(&problem)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Problem not found"})
+		return
+	}
+
+	if err := ensureStatementHTML(ctx, &problem); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render statement"})
+		return
+	}
+	if err := localizeProblem(ctx, c, &problem); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render statement"})
+		return
+	}
+
+	response := models.DailyResponse{Date: today, Problem: problem}
+
+	if userObjID, err := primitive.ObjectIDFromHex(c.GetString("userID")); err == nil {
+		var progress models.Progress
+		err := database.GetCollection("progress").FindOne(ctx, bson.M{
+			"user_id":    userObjID,
+			"problem_id": daily.ProblemID,
+		}).Decode(&progress)
+		response.Status = "unsolved"
+		if err == nil {
+			response.Status = progress.EffectiveStatus()
+		}
+		response.Solved = response.Status == "solved"
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func GetDailySchedule(c *gin.Context) {
+	filter := bson.M{}
+	dateRange := bson.M{}
+	if from := c.Query("from"); from != "" {
+		if _, err := time.Parse(dateLayout, from); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from must be YYYY-MM-DD"})
+			return
+		}
+		dateRange["$gte"] = from
+	}
+	if to := c.Query("to"); to != "" {
+		if _, err := time.Parse(dateLayout, to); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to must be YYYY-MM-DD"})
+			return
+		}
+		dateRange["$lte"] = to
+	}
+	if len(dateRange) > 0 {
+		filter["date"] = dateRange
+	}
+
+	collection := database.GetCollection("daily_challenges")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.M{"date": 1})
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch schedule"})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var schedule []models.DailyChallenge
+	if err := cursor.All(ctx, &schedule); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse schedule"})
+		return
+	}
+	if schedule == nil {
+		schedule = []models.DailyChallenge{}
+	}
+
+	c.JSON(http.StatusOK, schedule)
+}
+
+// ScheduleDailyChallenges plans one or more dates. Scheduling a date that
+// already has a challenge (planned or auto-picked) replaces it.
+func ScheduleDailyChallenges(c *gin.Context) {
+	var req models.DailyScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	entries := make([]models.DailyChallenge, 0, len(req.Entries))
+	problemIDs := map[primitive.ObjectID]bool{}
+	for _, entry := range req.Entries {
+		if _, err := time.Parse(dateLayout, entry.Date); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date: " + entry.Date})
+			return
+		}
+		problemObjID, err := resolveProblemID(entry.ProblemID)
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Problem not found: " + entry.ProblemID})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check problems"})
+			return
+		}
+		problemIDs[problemObjID] = true
+		entries = append(entries, models.DailyChallenge{
+			Date:      entry.Date,
+			ProblemID: problemObjID,
+			Source:    "scheduled",
+		})
+	}
+
+	collection := database.GetCollection("daily_challenges")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// resolveProblemID takes any well-formed ID at its word
+	ids := make([]primitive.ObjectID, 0, len(problemIDs))
+	for id := range problemIDs {
+		ids = append(ids, id)
+	}
+	count, err := database.GetCollection("problems").CountDocuments(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check problems"})
+		return
+	}
+	if int(count) != len(ids) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "One or more problems do not exist"})
+		return
+	}
+
+	now := time.Now()
+	for _, entry := range entries {
+		_, err := collection.UpdateOne(ctx, bson.M{"date": entry.Date}, bson.M{
+			"$set": bson.M{
+				"problem_id": entry.ProblemID,
+				"source":     entry.Source,
+				"created_at": now,
+			},
+		}, options.Update().SetUpsert(true))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to schedule " + entry.Date})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Schedule updated", "count": len(entries)})
+}
+
+func DeleteDailyChallenge(c *gin.Context) {
+	date := c.Param("date")
+
+	collection := database.GetCollection("daily_challenges")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, err := collection.DeleteOne(ctx, bson.M{"date": date})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete daily challenge"})
+		return
+	}
+	if result.DeletedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Nothing scheduled for " + date})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Daily challenge deleted"})
+}
+
+// dailyDate formats t as a calendar date in the configured timezone.
+func dailyDate(t time.Time) string {
+	loc, err := time.LoadLocation(config.AppConfig.DailyTimezone)
+	if err != nil {
+		log.Printf("Invalid DAILY_TIMEZONE %q, using UTC: %v", config.AppConfig.DailyTimezone, err)
+		loc = time.UTC
+	}
+	return t.In(loc).Format(dateLayout)
+}
+
+// dailyChallengeFor returns the challenge planned for date, picking and
+// storing one automatically if nothing was scheduled. The pick is seeded by
+// the date, and the insert is an upsert on the unique date index, so
+// concurrent first requests of the day agree on the same problem.
+func dailyChallengeFor(ctx context.Context, date string) (models.DailyChallenge, error) {
+	collection := database.GetCollection("daily_challenges")
+
+	var daily models.DailyChallenge
+	err := collection.FindOne(ctx, bson.M{"date": date}).Decode(&daily)
+	if err != mongo.ErrNoDocuments {
+		return daily, err
+	}
+
+	problemID, err := pickDailyProblem(ctx, date)
+	if err != nil {
+		return daily, err
+	}
+
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	err = collection.FindOneAndUpdate(ctx, bson.M{"date": date}, bson.M{
+		"$setOnInsert": bson.M{
+			"problem_id": problemID,
+			"source":     "auto",
+			"created_at": time.Now(),
+		},
+	}, opts).Decode(&daily)
+	if mongo.IsDuplicateKeyError(err) {
+		// Lost the race to another request; use what it stored
+		err = collection.FindOne(ctx, bson.M{"date": date}).Decode(&daily)
+	}
+	return daily, err
+}
+
+// pickDailyProblem draws a problem weighted by difficulty, skipping anything
+// featured in the last dailyRecentDays days unless that would leave nothing.
+func pickDailyProblem(ctx context.Context, date string) (primitive.ObjectID, error) {
+	day, _ := time.Parse(dateLayout, date)
+	since := day.AddDate(0, 0, -dailyRecentDays).Format(dateLayout)
+
+	recent, err := database.GetCollection("daily_challenges").Distinct(ctx, "problem_id", bson.M{
+		"date": bson.M{"$gte": since, "$lt": date},
+	})
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	opts := options.Find().SetProjection(bson.M{"_id": 1, "difficulty": 1})
+	cursor, err := database.GetCollection("problems").Find(ctx, bson.M{"_id": bson.M{"$nin": recent}}, opts)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	var candidates []models.ProblemListItem
+	if err := cursor.All(ctx, &candidates); err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	if len(candidates) == 0 {
+		cursor, err := database.GetCollection("problems").Find(ctx, bson.M{}, opts)
+		if err != nil {
+			return primitive.NilObjectID, err
+		}
+		if err := cursor.All(ctx, &candidates); err != nil {
+			return primitive.NilObjectID, err
+		}
+	}
+	if len(candidates) == 0 {
+		return primitive.NilObjectID, mongo.ErrNoDocuments
+	}
+
+	// Stable order so the same date and catalog always give the same pick
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].ID.Hex() < candidates[j].ID.Hex()
+	})
+
+	total := 0
+	for _, p := range candidates {
+		total += difficultyWeight(p.Difficulty)
+	}
+
+	seed := fnv.New64a()
+	seed.Write([]byte(date))
+	n := rand.New(rand.NewSource(int64(seed.Sum64()))).Intn(total)
+
+	for _, p := range candidates {
+		n -= difficultyWeight(p.Difficulty)
+		if n < 0 {
+			return p.ID, nil
+		}
+	}
+	return candidates[len(candidates)-1].ID, nil
+}
+
+func difficultyWeight(difficulty string) int {
+	if w, ok := dailyDifficultyWeights[difficulty]; ok {
+		return w
+	}
+	return 1
+}
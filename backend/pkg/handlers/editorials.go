@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"woohoodsa/pkg/database"
+	"woohoodsa/pkg/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func GetEditorial(c *gin.Context) {
+	userID := c.GetString("userID")
+	userObjID, _ := primitive.ObjectIDFromHex(userID)
+
+	problemObjID, err := resolveProblemID(c.Param("id"))
+	if err != nil {
+		problemLookupFailed(c, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Editorials are only served once the problem is solved or the solution
+	// has been revealed through RevealHint
+	var progress models.Progress
+	database.GetCollection("progress").FindOne(ctx, bson.M{
+		"user_id":    userObjID,
+		"problem_id": problemObjID,
+	}).Decode(&progress)
+
+	if progress.Status != "solved" && progress.RevealLevel < len(revealLevels) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Solve the problem or reveal the solution to view the editorial",
+			"code":  "EDITORIAL_LOCKED",
+		})
+		return
+	}
+
+	editorial, err := loadEditorial(ctx, problemObjID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch editorial"})
+		return
+	}
+	if editorial == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Editorial not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, editorial)
+}
+
+func UpsertEditorial(c *gin.Context) {
+	userID := c.GetString("userID")
+	userObjID, _ := primitive.ObjectIDFromHex(userID)
+
+	problemObjID, err := resolveProblemID(c.Param("id"))
+	if err != nil {
+		problemLookupFailed(c, err)
+		return
+	}
+
+	var req models.EditorialRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	count, err := database.GetCollection("problems").CountDocuments(ctx, bson.M{"_id": problemObjID})
+	if err != nil || count == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Problem not found"})
+		return
+	}
+
+	now := time.Now()
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	var editorial models.Editorial
+	err = database.GetCollection("editorials").FindOneAndUpdate(ctx, bson.M{"problem_id": problemObjID}, bson.M{
+		"$set": bson.M{
+			"approaches": req.Approaches,
+			"author_id":  userObjID,
+			"updated_at": now,
+		},
+		"$setOnInsert": bson.M{
+			"created_at": now,
+		},
+	}, opts).Decode(&editorial)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save editorial"})
+		return
+	}
+
+	c.JSON(http.StatusOK, editorial)
+}
+
+func DeleteEditorial(c *gin.Context) {
+	problemObjID, err := resolveProblemID(c.Param("id"))
+	if err != nil {
+		problemLookupFailed(c, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, err := database.GetCollection("editorials").DeleteOne(ctx, bson.M{"problem_id": problemObjID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete editorial"})
+		return
+	}
+	if result.DeletedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Editorial not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Editorial deleted"})
+}
+
+// loadEditorial returns the problem's editorial, nil if it has none. Problems
+// seeded before editorials existed still carry a best_solution field; that is
+// served as a single C++ "optimal" approach until an admin writes a real one.
+func loadEditorial(ctx context.Context, problemID primitive.ObjectID) (*models.Editorial, error) {
+	var editorial models.Editorial
+	err := database.GetCollection("editorials").FindOne(ctx, bson.M{"problem_id": problemID}).Decode(&editorial)
+	if err == nil {
+		return &editorial, nil
+	}
+	if err != mongo.ErrNoDocuments {
+		return nil, err
+	}
+
+	var legacy struct {
+		BestSolution string `bson:"best_solution"`
+	}
+	opts := options.FindOne().SetProjection(bson.M{"best_solution": 1})
+	err = database.GetCollection("problems").FindOne(ctx, bson.M{"_id": problemID}, opts).Decode(&legacy)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return nil, err
+	}
+	if legacy.BestSolution == "" {
+		return nil, nil
+	}
+
+	return &models.Editorial{
+		ProblemID: problemID,
+		Approaches: []models.Approach{{
+			Name:  "optimal",
+			Title: "Best Solution",
+			Code:  map[string]string{"cpp": legacy.BestSolution},
+		}},
+	}, nil
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"woohoodsa/pkg/config"
+	"woohoodsa/pkg/database"
+	"woohoodsa/pkg/models"
+	"woohoodsa/pkg/services"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// UpdateEmail sets the caller's email address and mails a verification link
+// to it. Sending the current unverified address again resends the link.
+func UpdateEmail(c *gin.Context) {
+	userObjID, _ := primitive.ObjectIDFromHex(c.GetString("userID"))
+
+	var req models.UpdateEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	email := normalizeEmail(req.Email)
+
+	collection := database.GetCollection("users")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var user models.User
+	if err := collection.FindOne(ctx, bson.M{"_id": userObjID}).Decode(&user); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if user.Email == email && user.EmailVerified {
+		c.JSON(http.StatusOK, gin.H{"message": "Email already verified", "email": email})
+		return
+	}
+
+	_, err := collection.UpdateOne(ctx, bson.M{"_id": userObjID}, bson.M{
+		"$set": bson.M{"email": email, "email_verified": false},
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Email already in use"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update email"})
+		return
+	}
+
+	user.Email = email
+	if err := sendVerificationEmail(ctx, user); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send verification email"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Verification email sent", "email": email})
+}
+
+func VerifyEmail(c *gin.Context) {
+	var req models.TokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	token, err := consumeUserToken(ctx, req.Token, "verify_email")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired verification link"})
+		return
+	}
+
+	result, err := database.GetCollection("users").UpdateOne(ctx, bson.M{
+		"_id":   token.UserID,
+		"email": token.Email,
+	}, bson.M{"$set": bson.M{"email_verified": true}})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify email"})
+		return
+	}
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Email has changed since this link was sent"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Email verified", "email": token.Email})
+}
+
+func sendVerificationEmail(ctx context.Context, user models.User) error {
+	token, err := issueUserToken(ctx, user, "verify_email", verifyEmailTTL)
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/auth/verify-email?token=%s", config.AppConfig.AppURL, url.QueryEscape(token))
+	return services.Mail.Send(ctx, services.Email{
+		To:      user.Email,
+		Subject: "Verify your email",
+		Body: fmt.Sprintf("Hi %s,\n\nConfirm this address for your account by opening the link below. It expires in %d hours.\n\n%s\n\nIf you didn't add this email, you can ignore this message.\n",
+			user.Username, int(verifyEmailTTL.Hours()), link),
+	})
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"woohoodsa/pkg/config"
+	"woohoodsa/pkg/database"
+	"woohoodsa/pkg/middleware"
+	"woohoodsa/pkg/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	loginFailureWindow = 24 * time.Hour // A failure streak is forgotten after this long without failures
+	lockoutBase        = time.Minute
+	lockoutMax         = time.Hour
+)
+
+// loginLockedFor returns how long the username stays locked, or 0. Lookup
+// errors don't lock anyone out.
+func loginLockedFor(ctx context.Context, username string) time.Duration {
+	var failure models.LoginFailure
+	err := database.GetCollection("login_failures").FindOne(ctx, bson.M{"_id": lockoutKey(username)}).Decode(&failure)
+	if err != nil || failure.LockedUntil == nil {
+		return 0
+	}
+	if remaining := time.Until(*failure.LockedUntil); remaining > 0 {
+		return remaining
+	}
+	return 0
+}
+
+// recordLoginFailure counts a failed password or 2FA code. From the
+// threshold on, each failure locks the username for twice as long as the
+// last, up to lockoutMax.
+func recordLoginFailure(ctx context.Context, username string) {
+	now := time.Now()
+	collection := database.GetCollection("login_failures")
+
+	var failure models.LoginFailure
+	err := collection.FindOneAndUpdate(ctx, bson.M{"_id": lockoutKey(username)}, bson.M{
+		"$inc": bson.M{"failures": 1},
+		"$set": bson.M{"last_failure_at": now, "expires_at": now.Add(loginFailureWindow)},
+	}, options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)).Decode(&failure)
+	if err != nil {
+		log.Printf("Failed to record login failure: %v", err)
+		return
+	}
+
+	over := failure.Failures - config.AppConfig.LoginLockoutThreshold
+	if config.AppConfig.LoginLockoutThreshold <= 0 || over < 0 {
+		return
+	}
+	lockout := lockoutMax
+	if over < 16 {
+		lockout = min(lockoutBase<<over, lockoutMax)
+	}
+	_, err = collection.UpdateOne(ctx, bson.M{"_id": failure.Username}, bson.M{
+		"$set": bson.M{"locked_until": now.Add(lockout), "expires_at": now.Add(lockout + loginFailureWindow)},
+	})
+	if err != nil {
+		log.Printf("Failed to lock username after repeated failures: %v", err)
+	}
+}
+
+// clearLoginFailures resets the streak after a complete sign-in.
+func clearLoginFailures(ctx context.Context, username string) {
+	if _, err := database.GetCollection("login_failures").DeleteOne(ctx, bson.M{"_id": lockoutKey(username)}); err != nil {
+		log.Printf("Failed to clear login failures: %v", err)
+	}
+}
+
+func lockedOut(c *gin.Context, remaining time.Duration) {
+	middleware.TooManyRequests(c, remaining, "Too many failed sign-in attempts, please try again later")
+}
+
+func lockoutKey(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"expvar"
+	"net/http"
+
+	"woohoodsa/pkg/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetMetrics reports this instance's counters. They reset on restart and
+// are not shared between instances.
+func GetMetrics(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"rateLimitRejections":  expvarCounts(middleware.RateLimitRejections),
+		"rateLimitStoreErrors": expvarCounts(middleware.RateLimitStoreErrors),
+	})
+}
+
+func expvarCounts(m *expvar.Map) map[string]int64 {
+	counts := map[string]int64{}
+	m.Do(func(kv expvar.KeyValue) {
+		if n, ok := kv.Value.(*expvar.Int); ok {
+			counts[kv.Key] = n.Value()
+		}
+	})
+	return counts
+}
@@ -0,0 +1,373 @@
+package handlers
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"woohoodsa/pkg/config"
+	"woohoodsa/pkg/database"
+	"woohoodsa/pkg/models"
+	"woohoodsa/pkg/services"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	oauthStateTTL = 10 * time.Minute
+	// The state is also kept in this cookie, so a callback only completes in
+	// the browser that started the flow. Without it an attacker could hand a
+	// victim the callback from their own flow and sign them in as the
+	// attacker, or have the victim link their provider to the attacker.
+	oauthStateCookie     = "oauth_state"
+	oauthStateCookiePath = "/api/auth/oauth"
+)
+
+var (
+	errIdentityLinked = errors.New("this account is already linked to another user")
+	errProviderLinked = errors.New("you already have an account from this provider linked")
+)
+
+// GetOAuthProviders lists the sign-in providers that are configured.
+func GetOAuthProviders(c *gin.Context) {
+	providers := []string{}
+	for name := range config.AppConfig.OAuthProviders {
+		providers = append(providers, name)
+	}
+	sort.Strings(providers)
+	c.JSON(http.StatusOK, providers)
+}
+
+// StartOAuth redirects the browser to the provider's consent page.
+// ?redirect= is the frontend path to return to once signed in.
+func StartOAuth(c *gin.Context) {
+	authURL, err := beginOAuth(c, nil)
+	if err != nil {
+		return
+	}
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// LinkOAuth starts the same flow for a signed-in user, linking the provider
+// account to them instead of signing in. The frontend navigates to the
+// returned URL, since a browser redirect can't carry the Authorization header.
+func LinkOAuth(c *gin.Context) {
+	userObjID, _ := primitive.ObjectIDFromHex(c.GetString("userID"))
+	authURL, err := beginOAuth(c, &userObjID)
+	if err != nil {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"url": authURL})
+}
+
+// OAuthCallback finishes the flow and sends the browser back to the frontend
+// with the session tokens in the URL fragment, which never reaches a server.
+func OAuthCallback(c *gin.Context) {
+	providerName := c.Param("provider")
+	provider, ok := config.AppConfig.OAuthProviders[providerName]
+	if !ok {
+		oauthRedirect(c, url.Values{"error": {"Unknown sign-in provider"}})
+		return
+	}
+	if denied := c.Query("error"); denied != "" {
+		oauthRedirect(c, url.Values{"error": {"Sign-in was cancelled"}})
+		return
+	}
+
+	// The cookie is single use like the state it guards
+	stateCookie, _ := c.Cookie(oauthStateCookie)
+	setOAuthStateCookie(c, "", -1)
+	if stateCookie == "" || subtle.ConstantTimeCompare([]byte(stateCookie), []byte(c.Query("state"))) != 1 {
+		oauthRedirect(c, url.Values{"error": {"Sign-in expired, please try again"}})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	var state models.OAuthState
+	err := database.GetCollection("oauth_states").FindOneAndDelete(ctx, bson.M{
+		"state_hash": services.HashToken(c.Query("state")),
+		"provider":   providerName,
+		"expires_at": bson.M{"$gt": time.Now()},
+	}).Decode(&state)
+	if err != nil {
+		oauthRedirect(c, url.Values{"error": {"Sign-in expired, please try again"}})
+		return
+	}
+
+	redirectURI := oauthRedirectURI(providerName)
+	accessToken, err := services.ExchangeCode(ctx, provider, redirectURI, c.Query("code"), state.CodeVerifier)
+	if err != nil {
+		log.Printf("OAuth code exchange with %s failed: %v", providerName, err)
+		oauthRedirect(c, url.Values{"error": {"Sign-in failed"}, "redirect": {state.RedirectPath}})
+		return
+	}
+	identity, err := services.FetchIdentity(ctx, provider, accessToken)
+	if err != nil {
+		log.Printf("OAuth identity lookup with %s failed: %v", providerName, err)
+		oauthRedirect(c, url.Values{"error": {"Sign-in failed"}, "redirect": {state.RedirectPath}})
+		return
+	}
+
+	user, err := oauthUser(ctx, providerName, identity, state.LinkUserID)
+	if err != nil {
+		message := "Sign-in failed"
+		if errors.Is(err, errIdentityLinked) || errors.Is(err, errProviderLinked) {
+			message = err.Error()
+		} else {
+			log.Printf("OAuth sign-in with %s failed: %v", providerName, err)
+		}
+		oauthRedirect(c, url.Values{"error": {message}, "redirect": {state.RedirectPath}})
+		return
+	}
+
+	if state.LinkUserID != nil {
+		oauthRedirect(c, url.Values{"linked": {providerName}, "redirect": {state.RedirectPath}})
+		return
+	}
+
+	if twoFactorEnabled(user) {
+		challenge, err := twoFactorChallenge(ctx, user)
+		if err != nil {
+			oauthRedirect(c, url.Values{"error": {"Failed to start two-factor sign-in"}, "redirect": {state.RedirectPath}})
+			return
+		}
+		oauthRedirect(c, url.Values{"challenge": {challenge.ChallengeToken}, "redirect": {state.RedirectPath}})
+		return
+	}
+
+	response, err := startSession(ctx, c, user)
+	if err != nil {
+		oauthRedirect(c, url.Values{"error": {"Failed to generate token"}, "redirect": {state.RedirectPath}})
+		return
+	}
+	oauthRedirect(c, url.Values{
+		"token":        {response.Token},
+		"refreshToken": {response.RefreshToken},
+		"redirect":     {state.RedirectPath},
+	})
+}
+
+// UnlinkOAuth removes a linked provider, as long as the user keeps some way
+// to sign in.
+func UnlinkOAuth(c *gin.Context) {
+	userObjID, _ := primitive.ObjectIDFromHex(c.GetString("userID"))
+	providerName := c.Param("provider")
+
+	collection := database.GetCollection("users")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var user models.User
+	if err := collection.FindOne(ctx, bson.M{"_id": userObjID}).Decode(&user); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	linked := false
+	for _, identity := range user.Identities {
+		if identity.Provider == providerName {
+			linked = true
+		}
+	}
+	if !linked {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Provider not linked"})
+		return
+	}
+	if user.PasswordHash == "" && len(user.Identities) == 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Set a password before unlinking your only sign-in method"})
+		return
+	}
+
+	_, err := collection.UpdateOne(ctx, bson.M{"_id": userObjID}, bson.M{
+		"$pull": bson.M{"identities": bson.M{"provider": providerName}},
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlink provider"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Provider unlinked"})
+}
+
+// beginOAuth stores a state with a fresh PKCE verifier and returns the
+// provider URL to send the browser to. It writes the error response itself.
+func beginOAuth(c *gin.Context, linkUserID *primitive.ObjectID) (string, error) {
+	providerName := c.Param("provider")
+	provider, ok := config.AppConfig.OAuthProviders[providerName]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown sign-in provider"})
+		return "", errors.New("unknown provider")
+	}
+
+	// Only same-site paths, so the flow can't be used as an open redirect
+	redirectPath := c.DefaultQuery("redirect", "/dashboard")
+	if !strings.HasPrefix(redirectPath, "/") || strings.HasPrefix(redirectPath, "//") || strings.Contains(redirectPath, `\`) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "redirect must be a path on this site"})
+		return "", errors.New("bad redirect")
+	}
+
+	state, stateHash, err := services.NewOpaqueToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start sign-in"})
+		return "", err
+	}
+	verifier, challenge, err := services.NewPKCE()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start sign-in"})
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err = database.GetCollection("oauth_states").InsertOne(ctx, models.OAuthState{
+		StateHash:    stateHash,
+		Provider:     providerName,
+		CodeVerifier: verifier,
+		RedirectPath: redirectPath,
+		LinkUserID:   linkUserID,
+		ExpiresAt:    time.Now().Add(oauthStateTTL),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start sign-in"})
+		return "", err
+	}
+
+	setOAuthStateCookie(c, state, int(oauthStateTTL.Seconds()))
+	return services.AuthCodeURL(provider, oauthRedirectURI(providerName), state, challenge), nil
+}
+
+// setOAuthStateCookie sets or, with a negative maxAge, clears the state
+// cookie. Lax still sends it on the provider's top-level redirect back to us.
+// When linking, the cookie comes from a credentialed XHR, so the frontend and
+// API must be on the same site for the browser to keep it.
+func setOAuthStateCookie(c *gin.Context, state string, maxAge int) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	secure := strings.HasPrefix(config.AppConfig.APIURL, "https://")
+	c.SetCookie(oauthStateCookie, state, maxAge, oauthStateCookiePath, "", secure, true)
+}
+
+// oauthUser finds or creates the user for a provider identity. In order: an
+// account already linked to it; the signed-in user when linking; an account
+// with the same verified email; otherwise a new account.
+func oauthUser(ctx context.Context, providerName string, identity *services.OAuthIdentity, linkUserID *primitive.ObjectID) (models.User, error) {
+	collection := database.GetCollection("users")
+	link := models.Identity{
+		Provider: providerName,
+		Subject:  identity.Subject,
+		Username: identity.Username,
+		LinkedAt: time.Now(),
+	}
+
+	var user models.User
+	err := collection.FindOne(ctx, bson.M{"identities": bson.M{"$elemMatch": bson.M{
+		"provider": providerName,
+		"subject":  identity.Subject,
+	}}}).Decode(&user)
+	if err == nil {
+		if linkUserID != nil && *linkUserID != user.ID {
+			return user, errIdentityLinked
+		}
+		return user, nil
+	}
+	if err != mongo.ErrNoDocuments {
+		return user, err
+	}
+
+	email := normalizeEmail(identity.Email)
+	filter := bson.M{}
+	switch {
+	case linkUserID != nil:
+		filter["_id"] = *linkUserID
+		filter["identities.provider"] = bson.M{"$ne": providerName}
+	case email != "" && identity.EmailVerified:
+		filter["email"] = email
+		filter["email_verified"] = true
+	}
+	if len(filter) > 0 {
+		err := collection.FindOneAndUpdate(ctx, filter, bson.M{
+			"$push": bson.M{"identities": link},
+		}).Decode(&user)
+		if err == nil {
+			user.Identities = append(user.Identities, link)
+			return user, nil
+		}
+		if err == mongo.ErrNoDocuments && linkUserID != nil {
+			return user, errProviderLinked
+		}
+		if err != mongo.ErrNoDocuments {
+			return user, err
+		}
+	}
+
+	username, err := availableUsername(ctx, identity.Username)
+	if err != nil {
+		return user, err
+	}
+	user = models.User{
+		ID:         primitive.NewObjectID(),
+		Username:   username,
+		Identities: []models.Identity{link},
+		CreatedAt:  time.Now(),
+	}
+	// Take the email only if nobody has it yet, verified or not
+	if email != "" && identity.EmailVerified {
+		if count, _ := collection.CountDocuments(ctx, bson.M{"email": email}); count == 0 {
+			user.Email = email
+			user.EmailVerified = true
+		}
+	}
+	_, err = collection.InsertOne(ctx, user)
+	return user, err
+}
+
+// availableUsername turns a provider username into one that fits our rules
+// and isn't taken, adding a number if needed.
+func availableUsername(ctx context.Context, wanted string) (string, error) {
+	var b strings.Builder
+	for _, r := range wanted {
+		if r < 128 && (r == '_' || r == '-' || r == '.' || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9')) {
+			b.WriteRune(r)
+		}
+	}
+	base := b.String()
+	if len(base) > 26 {
+		base = base[:26]
+	}
+	if len(base) < 3 {
+		base = "user"
+	}
+
+	candidate := base
+	for n := 2; n < 1000; n++ {
+		taken, err := usernameTaken(ctx, candidate, primitive.NilObjectID)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return candidate, nil
+		}
+		candidate = fmt.Sprintf("%s%d", base, n)
+	}
+	return "", errors.New("no free username for " + base)
+}
+
+func oauthRedirectURI(providerName string) string {
+	return strings.TrimRight(config.AppConfig.APIURL, "/") + "/api/auth/oauth/" + providerName + "/callback"
+}
+
+func oauthRedirect(c *gin.Context, fragment url.Values) {
+	c.Redirect(http.StatusFound, strings.TrimRight(config.AppConfig.AppURL, "/")+"/auth/callback#"+fragment.Encode())
+}
@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const maxPageSize = 100
+
+// listParams is the parsed form of the query parameters shared by every list
+// endpoint: ?limit=, ?sort= and the opaque ?next= cursor from a previous page.
+type listParams struct {
+	Limit    int64
+	SortName string
+	Sort     bson.D // Always ends with _id so the order is total
+	after    *pageCursor
+}
+
+// pageCursor is what the opaque `next` token decodes to: the sort key values
+// of the last item on the previous page. The sort name is included so a token
+// can't be replayed against a different ordering.
+type pageCursor struct {
+	Sort   string `bson:"s"`
+	Values bson.A `bson:"v"`
+}
+
+func parseListParams(c *gin.Context, sorts map[string]bson.D, defaultSort string, defaultLimit int64) (listParams, error) {
+	params := listParams{
+		Limit:    defaultLimit,
+		SortName: c.DefaultQuery("sort", defaultSort),
+	}
+
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || limit < 1 {
+			return params, errors.New("limit must be a positive integer")
+		}
+		if limit > maxPageSize {
+			limit = maxPageSize
+		}
+		params.Limit = limit
+	}
+
+	keys, ok := sorts[params.SortName]
+	if !ok {
+		names := make([]string, 0, len(sorts))
+		for name := range sorts {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return params, errors.New("sort must be one of: " + strings.Join(names, ", "))
+	}
+	params.Sort = append(append(bson.D{}, keys...), bson.E{Key: "_id", Value: keys[len(keys)-1].Value})
+
+	if token := c.Query("next"); token != "" {
+		raw, err := base64.RawURLEncoding.DecodeString(token)
+		if err != nil {
+			return params, errors.New("Invalid next token")
+		}
+		var cursor pageCursor
+		if err := bson.Unmarshal(raw, &cursor); err != nil || cursor.Sort != params.SortName || len(cursor.Values) != len(params.Sort) {
+			return params, errors.New("Invalid next token")
+		}
+		params.after = &cursor
+	}
+
+	return params, nil
+}
+
+// seekFilter matches the documents strictly after the cursor in sort order,
+// or nil on the first page. For a sort on (a, b, _id) that is
+//
+//	a > va OR (a == va AND b > vb) OR (a == va AND b == vb AND _id > vid)
+//
+// with > flipped to < for descending keys. Mongo sorts missing and null
+// values before everything else, so "after null" ascending is any value at
+// all, nothing is after null descending, and "before v" descending includes
+// the nulls that trail the page.
+func (p listParams) seekFilter() bson.M {
+	if p.after == nil {
+		return nil
+	}
+
+	clauses := bson.A{}
+	for i, key := range p.Sort {
+		clause := bson.M{}
+		for j := 0; j < i; j++ {
+			// Equality with null also matches a missing field
+			clause[p.Sort[j].Key] = p.after.Values[j]
+		}
+		value := p.after.Values[i]
+		descending := key.Value == -1
+		switch {
+		case value == nil && descending:
+			continue
+		case value == nil:
+			clause[key.Key] = bson.M{"$ne": nil}
+		case descending:
+			clause["$or"] = bson.A{
+				bson.M{key.Key: bson.M{"$lt": value}},
+				bson.M{key.Key: nil},
+			}
+		default:
+			clause[key.Key] = bson.M{"$gt": value}
+		}
+		clauses = append(clauses, clause)
+	}
+	return bson.M{"$or": clauses}
+}
+
+// withSeek ANDs the cursor position onto a handler's own filter.
+func (p listParams) withSeek(filter bson.M) bson.M {
+	seek := p.seekFilter()
+	if seek == nil {
+		return filter
+	}
+	return bson.M{"$and": bson.A{filter, seek}}
+}
+
+// findOptions fetches one extra document so collectPage can tell whether
+// there is another page without a second query.
+func (p listParams) findOptions() *options.FindOptions {
+	return options.Find().SetSort(p.Sort).SetLimit(p.Limit + 1)
+}
+
+// collectPage decodes up to p.Limit documents from cursor and returns the
+// token for the following page, or "" if this was the last one.
+func collectPage[T any](ctx context.Context, cursor *mongo.Cursor, p listParams) ([]T, string, error) {
+	var raws []bson.Raw
+	if err := cursor.All(ctx, &raws); err != nil {
+		return nil, "", err
+	}
+	return decodePage[T](raws, p)
+}
+
+func decodePage[T any](raws []bson.Raw, p listParams) ([]T, string, error) {
+	next := ""
+	if int64(len(raws)) > p.Limit {
+		raws = raws[:p.Limit]
+		last := raws[len(raws)-1]
+
+		cursor := pageCursor{Sort: p.SortName}
+		for _, key := range p.Sort {
+			// Missing and null both sort as null
+			value, err := last.LookupErr(strings.Split(key.Key, ".")...)
+			if err != nil || value.Type == bson.TypeNull {
+				cursor.Values = append(cursor.Values, nil)
+				continue
+			}
+			cursor.Values = append(cursor.Values, value)
+		}
+		raw, err := bson.Marshal(cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		next = base64.RawURLEncoding.EncodeToString(raw)
+	}
+
+	items := make([]T, 0, len(raws))
+	for _, raw := range raws {
+		var item T
+		if err := bson.Unmarshal(raw, &item); err != nil {
+			return nil, "", err
+		}
+		items = append(items, item)
+	}
+	return items, next, nil
+}
+
+// writeList sends a list envelope, trimming each item down to the fields
+// named in ?fields= when present. "id" is always kept.
+func writeList(c *gin.Context, response interface{}) {
+	fields := queryList(c, "fields")
+	if len(fields) == 0 {
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	keep := map[string]bool{"id": true}
+	for _, f := range fields {
+		keep[f] = true
+	}
+
+	raw, err := json.Marshal(response)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encode response"})
+		return
+	}
+	var body map[string]interface{}
+	json.Unmarshal(raw, &body)
+
+	items, _ := body["items"].([]interface{})
+	for _, item := range items {
+		if m, ok := item.(map[string]interface{}); ok {
+			for key := range m {
+				if !keep[key] {
+					delete(m, key)
+				}
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, body)
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type pageItem struct {
+	ID primitive.ObjectID `bson:"_id"`
+}
+
+// nextCursor runs decodePage over docs and decodes the token it returns.
+func nextCursor(t *testing.T, p listParams, docs ...bson.M) *pageCursor {
+	t.Helper()
+	raws := make([]bson.Raw, 0, len(docs))
+	for _, doc := range docs {
+		raw, err := bson.Marshal(doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		raws = append(raws, raw)
+	}
+
+	items, next, err := decodePage[pageItem](raws, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if int64(len(items)) != p.Limit || next == "" {
+		t.Fatalf("got %d items and next %q, want %d items and a token", len(items), next, p.Limit)
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(next)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cursor pageCursor
+	if err := bson.Unmarshal(raw, &cursor); err != nil {
+		t.Fatal(err)
+	}
+	return &cursor
+}
+
+func TestSeekAfterMissingSortKey(t *testing.T) {
+	id := primitive.NewObjectID()
+	params := listParams{
+		Limit:    1,
+		SortName: "sequence",
+		Sort:     bson.D{{Key: "topic_sequence", Value: 1}, {Key: "_id", Value: 1}},
+	}
+	// The last row on the page has no topic_sequence, and an explicit null
+	// must behave the same
+	for _, last := range []bson.M{{"_id": id}, {"_id": id, "topic_sequence": nil}} {
+		params.after = nextCursor(t, params, last, bson.M{"_id": primitive.NewObjectID(), "topic_sequence": 1})
+		if params.after.Values[0] != nil {
+			t.Fatalf("cursor value for a missing key = %v, want nil", params.after.Values[0])
+		}
+
+		want := bson.M{"$or": bson.A{
+			bson.M{"topic_sequence": bson.M{"$ne": nil}},
+			bson.M{"topic_sequence": nil, "_id": bson.M{"$gt": id}},
+		}}
+		if got := params.seekFilter(); !reflect.DeepEqual(got, want) {
+			t.Errorf("seekFilter() = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestSeekDescendingKeepsMissingKeys(t *testing.T) {
+	id := primitive.NewObjectID()
+	created := primitive.NewDateTimeFromTime(id.Timestamp())
+	params := listParams{
+		Limit:    1,
+		SortName: "newest",
+		Sort:     bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}},
+	}
+
+	// Rows without created_at sort after every dated row, so the page after
+	// a dated row must still reach them
+	params.after = nextCursor(t, params, bson.M{"_id": id, "created_at": created}, bson.M{"_id": primitive.NewObjectID()})
+	want := bson.M{"$or": bson.A{
+		bson.M{"$or": bson.A{bson.M{"created_at": bson.M{"$lt": created}}, bson.M{"created_at": nil}}},
+		bson.M{"created_at": created, "$or": bson.A{bson.M{"_id": bson.M{"$lt": id}}, bson.M{"_id": nil}}},
+	}}
+	if got := params.seekFilter(); !reflect.DeepEqual(got, want) {
+		t.Errorf("seekFilter() = %v, want %v", got, want)
+	}
+
+	// Once into the undated rows, nothing sorts after null; only the _id
+	// tie-break remains
+	params.after = nextCursor(t, params, bson.M{"_id": id}, bson.M{"_id": primitive.NewObjectID()})
+	want = bson.M{"$or": bson.A{
+		bson.M{"created_at": nil, "$or": bson.A{bson.M{"_id": bson.M{"$lt": id}}, bson.M{"_id": nil}}},
+	}}
+	if got := params.seekFilter(); !reflect.DeepEqual(got, want) {
+		t.Errorf("seekFilter() = %v, want %v", got, want)
+	}
+}
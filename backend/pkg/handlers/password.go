@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"woohoodsa/pkg/config"
+	"woohoodsa/pkg/database"
+	"woohoodsa/pkg/models"
+	"woohoodsa/pkg/services"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ChangePassword requires the current password and signs out every other
+// device.
+func ChangePassword(c *gin.Context) {
+	userObjID, _ := primitive.ObjectIDFromHex(c.GetString("userID"))
+
+	var req models.ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	collection := database.GetCollection("users")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var user models.User
+	if err := collection.FindOne(ctx, bson.M{"_id": userObjID}).Decode(&user); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	// Not 401: the caller is authenticated, the old password is just wrong.
+	// Accounts created through a sign-in provider have no password to check.
+	if user.PasswordHash == "" {
+		if req.OldPassword != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "This account has no password yet; leave the old password empty"})
+			return
+		}
+	} else if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.OldPassword)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Old password is incorrect"})
+		return
+	}
+
+	if err := setPassword(ctx, userObjID, req.NewPassword); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update password"})
+		return
+	}
+
+	currentSession, _ := primitive.ObjectIDFromHex(c.GetString("sessionID"))
+	if err := revokeUserSessions(ctx, userObjID, currentSession, "password_changed"); err != nil {
+		log.Printf("Failed to revoke sessions for user %s: %v", userObjID.Hex(), err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password updated successfully"})
+}
+
+// ForgotPassword mails a reset link to a verified address. The response is
+// the same whether or not the address belongs to anyone, so it can't be used
+// to discover accounts.
+func ForgotPassword(c *gin.Context) {
+	var req models.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var user models.User
+	err := database.GetCollection("users").FindOne(ctx, bson.M{
+		"email":          normalizeEmail(req.Email),
+		"email_verified": true,
+	}).Decode(&user)
+	if err == nil {
+		if err := sendPasswordResetEmail(ctx, user); err != nil {
+			log.Printf("Failed to send password reset to user %s: %v", user.ID.Hex(), err)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "If that email belongs to a verified account, a reset link is on its way"})
+}
+
+// ResetPassword sets a new password from a mailed reset link and signs the
+// user out everywhere.
+func ResetPassword(c *gin.Context) {
+	var req models.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	token, err := consumeUserToken(ctx, req.Token, "reset_password")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired reset link"})
+		return
+	}
+
+	if err := setPassword(ctx, token.UserID, req.NewPassword); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update password"})
+		return
+	}
+	if err := revokeUserSessions(ctx, token.UserID, primitive.NilObjectID, "password_reset"); err != nil {
+		log.Printf("Failed to revoke sessions for user %s: %v", token.UserID.Hex(), err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password reset; sign in with your new password"})
+}
+
+func setPassword(ctx context.Context, userID primitive.ObjectID, password string) error {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	_, err = database.GetCollection("users").UpdateOne(ctx, bson.M{"_id": userID}, bson.M{
+		"$set": bson.M{"password_hash": string(hashedPassword)},
+	})
+	return err
+}
+
+func sendPasswordResetEmail(ctx context.Context, user models.User) error {
+	token, err := issueUserToken(ctx, user, "reset_password", resetPasswordTTL)
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/auth/reset-password?token=%s", config.AppConfig.AppURL, url.QueryEscape(token))
+	return services.Mail.Send(ctx, services.Email{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nSomeone asked to reset the password for your account. Open the link below to choose a new one. It expires in %d minutes and works once.\n\n%s\n\nIf this wasn't you, you can ignore this message; your password hasn't changed.\n",
+			user.Username, int(resetPasswordTTL.Minutes()), link),
+	})
+}
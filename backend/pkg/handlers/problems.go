@@ -2,7 +2,11 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
 	"time"
 
 	"woohoodsa/pkg/database"
@@ -11,6 +15,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
@@ -21,6 +26,21 @@ func GetProblems(c *gin.Context) {
 
 	topic := c.Query("topic")
 	difficulty := c.Query("difficulty")
+	q := strings.TrimSpace(c.Query("q"))
+	tags := queryList(c, "tags")
+	companies := queryList(c, "companies")
+
+	// match=any ORs the values within tags and within companies; the default
+	// "all" requires every listed value. Different params are always ANDed.
+	listOp := "$all"
+	switch c.DefaultQuery("match", "all") {
+	case "all":
+	case "any":
+		listOp = "$in"
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "match must be 'all' or 'any'"})
+		return
+	}
 
 	filter := bson.M{}
 	if topic != "" {
@@ -29,43 +49,425 @@ func GetProblems(c *gin.Context) {
 	if difficulty != "" {
 		filter["difficulty"] = difficulty
 	}
+	if len(tags) > 0 {
+		filter["tags"] = bson.M{listOp: tags}
+	}
+	if len(companies) > 0 {
+		filter["companies"] = bson.M{listOp: companies}
+	}
+	if q != "" {
+		filter["$text"] = bson.M{"$search": q}
+	}
 
-	// Include topic_sequence and sort by it for proper ordering
-	opts := options.Find().
-		SetProjection(bson.M{
+	defaultSort := "default"
+	if q != "" {
+		defaultSort = "relevance"
+	}
+	params, err := parseListParams(c, problemSorts, defaultSort, 50)
+	if err == nil && params.SortName == "relevance" && q == "" {
+		err = errors.New("sort=relevance requires q")
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Computed sort keys live on the document so the page cursor can seek on them
+	computed := bson.M{
+		"difficulty_rank": bson.M{"$indexOfArray": bson.A{difficultyOrder, "$difficulty"}},
+		"acceptance_rate": bson.M{"$ifNull": bson.A{"$stats.acceptance_rate", 0}},
+	}
+	if q != "" {
+		computed["score"] = bson.M{"$meta": "textScore"}
+	}
+	if locale := requestLocale(ctx, c); locale != defaultLocale() {
+		computed["title"] = bson.M{"$ifNull": bson.A{"$translations." + locale + ".title", "$title"}}
+		c.Header("Content-Language", locale)
+	}
+
+	// Signed-in callers get their own status joined in from progress and can
+	// filter on it. Anonymous callers see the plain list.
+	status := c.Query("status")
+	userObjID, authErr := primitive.ObjectIDFromHex(c.GetString("userID"))
+	if status != "" && authErr != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Sign in to filter by status"})
+		return
+	}
+	switch status {
+	case "", "solved", "attempted", "unsolved":
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be one of: solved, attempted, unsolved"})
+		return
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$addFields", Value: computed}},
+	}
+	if authErr == nil {
+		pipeline = append(pipeline, userProgressStages(userObjID)...)
+		if status != "" {
+			pipeline = append(pipeline, bson.D{{Key: "$match", Value: bson.M{"status": status}}})
+		}
+	}
+
+	items := bson.A{}
+	if seek := params.seekFilter(); seek != nil {
+		items = append(items, bson.M{"$match": seek})
+	}
+	items = append(items,
+		bson.M{"$sort": params.Sort},
+		bson.M{"$limit": params.Limit + 1},
+		bson.M{"$project": bson.M{
 			"_id":            1,
 			"title":          1,
 			"slug":           1,
 			"difficulty":     1,
 			"topic":          1,
 			"topic_sequence": 1,
-		}).
-		SetSort(bson.D{
-			{Key: "topic_sequence", Value: 1},
-			{Key: "title", Value: 1},
-		})
+			"tags":           1,
+			"companies":      1,
+			"status":         1,
+			"attempts":       1,
+			"bookmarked":     1,
+			// Sort keys, needed to build the next token
+			"difficulty_rank": 1,
+			"acceptance_rate": 1,
+			"created_at":      1,
+			"score":           1,
+		}},
+	)
 
-	cursor, err := collection.Find(ctx, filter, opts)
+	facet := func(field string) bson.A {
+		return bson.A{
+			bson.M{"$group": bson.M{"_id": "$" + field, "count": bson.M{"$sum": 1}}},
+			bson.M{"$sort": bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}},
+		}
+	}
+	unwound := func(field string) bson.A {
+		return append(bson.A{bson.M{"$unwind": "$" + field}}, facet(field)...)
+	}
+
+	pipeline = append(pipeline,
+		bson.D{{Key: "$facet", Value: bson.M{
+			"items":        items,
+			"total":        bson.A{bson.M{"$count": "count"}},
+			"topics":       facet("topic"),
+			"difficulties": facet("difficulty"),
+			"tags":         unwound("tags"),
+			"companies":    unwound("companies"),
+		}}},
+	)
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch problems"})
 		return
 	}
 	defer cursor.Close(ctx)
 
-	var problems []models.ProblemListItem
-	if err := cursor.All(ctx, &problems); err != nil {
+	var results []struct {
+		Items []bson.Raw `bson:"items"`
+		Total []struct {
+			Count int64 `bson:"count"`
+		} `bson:"total"`
+		models.ProblemFacets `bson:",inline"`
+	}
+	if err := cursor.All(ctx, &results); err != nil || len(results) == 0 {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse problems"})
+		return
+	}
+
+	problems, next, err := decodePage[models.ProblemListItem](results[0].Items, params)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse problems"})
 		return
 	}
 
-	c.JSON(http.StatusOK, problems)
+	response := models.ProblemListResponse{Facets: results[0].ProblemFacets}
+	response.Items = problems
+	response.Next = next
+	if len(results[0].Total) > 0 {
+		response.Total = results[0].Total[0].Count
+	}
+
+	writeList(c, response)
+}
+
+// GetRandomProblem picks one problem matching the optional topic, difficulty
+// and tags filters. Signed-in callers never get a problem they've solved.
+func GetRandomProblem(c *gin.Context) {
+	collection := database.GetCollection("problems")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{}
+	if topic := c.Query("topic"); topic != "" {
+		filter["topic"] = topic
+	}
+	if difficulty := c.Query("difficulty"); difficulty != "" {
+		filter["difficulty"] = difficulty
+	}
+	if tags := queryList(c, "tags"); len(tags) > 0 {
+		filter["tags"] = bson.M{"$all": tags}
+	}
+
+	pipeline := mongo.Pipeline{{{Key: "$match", Value: filter}}}
+	if userObjID, err := primitive.ObjectIDFromHex(c.GetString("userID")); err == nil {
+		pipeline = append(pipeline, userProgressStages(userObjID)...)
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: bson.M{"status": bson.M{"$ne": "solved"}}}})
+	}
+	pipeline = append(pipeline,
+		bson.D{{Key: "$sample", Value: bson.M{"size": 1}}},
+		bson.D{{Key: "$project", Value: bson.M{
+			"hint_brute":     0,
+			"hint_optimized": 0,
+			"progress":       0,
+		}}},
+	)
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to pick a problem"})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var problems []models.Problem
+	if err := cursor.All(ctx, &problems); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse problem"})
+		return
+	}
+	if len(problems) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No unsolved problems match these filters"})
+		return
+	}
+
+	if err := ensureStatementHTML(ctx, &problems[0]); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render statement"})
+		return
+	}
+	if err := localizeProblem(ctx, c, &problems[0]); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render statement"})
+		return
+	}
+
+	c.JSON(http.StatusOK, problems[0])
+}
+
+// userProgressStages joins the user's progress document onto each problem
+// and flattens it into status/attempts/bookmarked, defaulting to "unsolved".
+// A problem with attempts but no solve always reads "attempted".
+func userProgressStages(userID primitive.ObjectID) []bson.D {
+	first := func(field string) bson.M {
+		return bson.M{"$arrayElemAt": bson.A{"$progress." + field, 0}}
+	}
+	return []bson.D{
+		{{Key: "$lookup", Value: bson.M{
+			"from": "progress",
+			"let":  bson.M{"problemId": "$_id"},
+			"pipeline": bson.A{
+				bson.M{"$match": bson.M{
+					"user_id": userID,
+					"$expr":   bson.M{"$eq": bson.A{"$problem_id", "$$problemId"}},
+				}},
+				bson.M{"$project": bson.M{"status": 1, "attempts": 1, "bookmarked": 1}},
+			},
+			"as": "progress",
+		}}},
+		{{Key: "$addFields", Value: bson.M{
+			"status":     bson.M{"$ifNull": bson.A{first("status"), "unsolved"}},
+			"attempts":   bson.M{"$ifNull": bson.A{first("attempts"), 0}},
+			"bookmarked": bson.M{"$ifNull": bson.A{first("bookmarked"), false}},
+		}}},
+		// Same rule as models.Progress.EffectiveStatus
+		{{Key: "$set", Value: bson.M{"status": bson.M{"$cond": bson.A{
+			bson.M{"$and": bson.A{
+				bson.M{"$eq": bson.A{"$status", "unsolved"}},
+				bson.M{"$gt": bson.A{"$attempts", 0}},
+			}},
+			"attempted",
+			"$status",
+		}}}}},
+	}
+}
+
+var difficultyOrder = bson.A{"Easy", "Medium", "Hard"}
+
+// problemListProjection loads just the fields of a models.ProblemListItem
+// from a problem document. The acceptance rate is kept under stats.
+var problemListProjection = bson.M{
+	"_id":             1,
+	"title":           1,
+	"slug":            1,
+	"difficulty":      1,
+	"topic":           1,
+	"topic_sequence":  1,
+	"tags":            1,
+	"companies":       1,
+	"acceptance_rate": bson.M{"$ifNull": bson.A{"$stats.acceptance_rate", 0}},
+}
+
+var problemSorts = map[string]bson.D{
+	// Include topic_sequence and sort by it for proper ordering
+	"default":     {{Key: "topic_sequence", Value: 1}, {Key: "title", Value: 1}},
+	"relevance":   {{Key: "score", Value: -1}},
+	"difficulty":  {{Key: "difficulty_rank", Value: 1}, {Key: "topic_sequence", Value: 1}},
+	"-difficulty": {{Key: "difficulty_rank", Value: -1}, {Key: "topic_sequence", Value: -1}},
+	"newest":      {{Key: "created_at", Value: -1}},
+	"acceptance":  {{Key: "acceptance_rate", Value: 1}, {Key: "topic_sequence", Value: 1}},
+	"-acceptance": {{Key: "acceptance_rate", Value: -1}, {Key: "topic_sequence", Value: -1}},
+}
+
+// queryList reads a multi-valued query parameter given either repeated
+// (?tags=a&tags=b) or comma-separated (?tags=a,b).
+func queryList(c *gin.Context, key string) []string {
+	var values []string
+	for _, raw := range c.QueryArray(key) {
+		for _, v := range strings.Split(raw, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				values = append(values, v)
+			}
+		}
+	}
+	return values
 }
 
 func GetProblem(c *gin.Context) {
-	id := c.Param("id")
-	objectID, err := primitive.ObjectIDFromHex(id)
+	objectID, err := resolveProblemID(c.Param("id"))
+	if err != nil {
+		problemLookupFailed(c, err)
+		return
+	}
+
+	collection := database.GetCollection("problems")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var problem models.Problem
+	err = collection.FindOne(ctx, bson.M{"_id": objectID}, problemDetailOptions()).Decode(&problem)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Problem not found"})
+		return
+	}
+
+	if err := ensureStatementHTML(ctx, &problem); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render statement"})
+		return
+	}
+	if err := localizeProblem(ctx, c, &problem); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render statement"})
+		return
+	}
+
+	if err := attachRelated(ctx, c, &problem); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch related problems"})
+		return
+	}
+
+	c.JSON(http.StatusOK, problem)
+}
+
+// problemDetailOptions leaves out the hints, which are only served through
+// RevealHint.
+func problemDetailOptions() *options.FindOneOptions {
+	return options.FindOne().SetProjection(bson.M{
+		"hint_brute":     0,
+		"hint_optimized": 0,
+	})
+}
+
+// GetProblemBySlug serves the canonical slug and redirects slugs the problem
+// used to have, so old links keep working after a rename.
+func GetProblemBySlug(c *gin.Context) {
+	slug := c.Param("slug")
+
+	collection := database.GetCollection("problems")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var problem models.Problem
+	err := collection.FindOne(ctx, bson.M{"slug": slug}, problemDetailOptions()).Decode(&problem)
+	if err == nil {
+		if err := ensureStatementHTML(ctx, &problem); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render statement"})
+			return
+		}
+		if err := localizeProblem(ctx, c, &problem); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render statement"})
+			return
+		}
+
+		if err := attachRelated(ctx, c, &problem); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch related problems"})
+			return
+		}
+		c.JSON(http.StatusOK, problem)
+		return
+	}
+
+	err = collection.FindOne(ctx, bson.M{"previous_slugs": slug}).Decode(&problem)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Problem not found"})
+		return
+	}
+
+	c.Redirect(http.StatusMovedPermanently, "/api/problems/by-slug/"+url.PathEscape(problem.Slug))
+}
+
+// resolveProblemID accepts either a hex ObjectID or a slug (current or
+// previous) wherever the API takes a problem ID. Hex IDs are returned without
+// a database round trip, matching the old behaviour.
+func resolveProblemID(idOrSlug string) (primitive.ObjectID, error) {
+	if objectID, err := primitive.ObjectIDFromHex(idOrSlug); err == nil {
+		return objectID, nil
+	}
+	if idOrSlug == "" {
+		return primitive.NilObjectID, mongo.ErrNoDocuments
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var problem struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	// RenameProblemSlug keeps current and previous slugs disjoint across
+	// problems, so at most one document can match
+	opts := options.FindOne().SetProjection(bson.M{"_id": 1})
+	err := database.GetCollection("problems").FindOne(ctx, bson.M{
+		"$or": bson.A{
+			bson.M{"slug": idOrSlug},
+			bson.M{"previous_slugs": idOrSlug},
+		},
+	}, opts).Decode(&problem)
+	return problem.ID, err
+}
+
+// problemLookupFailed answers a failed resolveProblemID: 404 if nothing
+// matched, 500 if the lookup itself failed.
+func problemLookupFailed(c *gin.Context, err error) {
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Problem not found"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch problem"})
+}
+
+func RenameProblemSlug(c *gin.Context) {
+	objectID, err := resolveProblemID(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid problem ID"})
+		problemLookupFailed(c, err)
+		return
+	}
+
+	var req models.RenameSlugRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !slugPattern.MatchString(req.Slug) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Slug must be lowercase letters, digits and hyphens"})
 		return
 	}
 
@@ -79,10 +481,57 @@ func GetProblem(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Problem not found"})
 		return
 	}
+	if problem.Slug == req.Slug {
+		c.JSON(http.StatusOK, problem)
+		return
+	}
+
+	// A slug may not be reused while another problem still redirects from it
+	taken, err := collection.CountDocuments(ctx, bson.M{
+		"_id": bson.M{"$ne": objectID},
+		"$or": bson.A{
+			bson.M{"slug": req.Slug},
+			bson.M{"previous_slugs": req.Slug},
+		},
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check slug"})
+		return
+	}
+	if taken > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Slug already in use"})
+		return
+	}
+
+	previous := []string{}
+	for _, old := range append(problem.PreviousSlugs, problem.Slug) {
+		if old != "" && old != req.Slug {
+			previous = append(previous, old)
+		}
+	}
+
+	_, err = collection.UpdateOne(ctx, bson.M{"_id": objectID}, bson.M{
+		"$set": bson.M{
+			"slug":           req.Slug,
+			"previous_slugs": previous,
+		},
+	})
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Slug already in use"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rename slug"})
+		return
+	}
 
+	problem.Slug = req.Slug
+	problem.PreviousSlugs = previous
 	c.JSON(http.StatusOK, problem)
 }
 
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
 func GetTopics(c *gin.Context) {
 	collection := database.GetCollection("problems")
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
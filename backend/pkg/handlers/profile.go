@@ -0,0 +1,281 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"woohoodsa/pkg/database"
+	"woohoodsa/pkg/middleware"
+	"woohoodsa/pkg/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// How often a user may change their username
+	usernameChangeCooldown = 7 * 24 * time.Hour
+	// How long a given-up username stays reserved, so nobody else can pick
+	// it up while old links and mentions still point at its owner
+	usernameHoldPeriod = 90 * 24 * time.Hour
+)
+
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// ChangeUsername renames the caller and rewrites the copies of their name
+// stored on comments and study lists. The response carries a fresh access
+// token, since the old one still has the old name in it.
+func ChangeUsername(c *gin.Context) {
+	userObjID, _ := primitive.ObjectIDFromHex(c.GetString("userID"))
+
+	var req models.ChangeUsernameRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !usernamePattern.MatchString(req.Username) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Username may only contain letters, digits, '.', '_' and '-'"})
+		return
+	}
+
+	users := database.GetCollection("users")
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var user models.User
+	if err := users.FindOne(ctx, bson.M{"_id": userObjID}).Decode(&user); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if user.Username == req.Username {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "That is already your username"})
+		return
+	}
+
+	now := time.Now()
+	if n := len(user.UsernameHistory); n > 0 {
+		if wait := user.UsernameHistory[n-1].ChangedAt.Add(usernameChangeCooldown).Sub(now); wait > 0 {
+			middleware.TooManyRequests(c, wait, "Username was changed recently, please try again later")
+			return
+		}
+	}
+
+	taken, err := usernameTaken(ctx, req.Username, userObjID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check username"})
+		return
+	}
+	if taken {
+		c.JSON(http.StatusConflict, gin.H{"error": "Username already taken"})
+		return
+	}
+
+	session, err := database.Client.StartSession()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change username"})
+		return
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		_, err := users.UpdateOne(sc, bson.M{"_id": userObjID}, bson.M{
+			"$set":  bson.M{"username": req.Username},
+			"$push": bson.M{"username_history": models.UsernameChange{Username: user.Username, ChangedAt: now}},
+		})
+		if err != nil {
+			return nil, err
+		}
+		_, err = database.GetCollection("comments").UpdateMany(sc, bson.M{"user_id": userObjID},
+			bson.M{"$set": bson.M{"username": req.Username}})
+		if err != nil {
+			return nil, err
+		}
+		_, err = database.GetCollection("study_lists").UpdateMany(sc, bson.M{"owner_id": userObjID},
+			bson.M{"$set": bson.M{"owner_username": req.Username}})
+		return nil, err
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Username already taken"})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to rename user %s: %v", userObjID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change username"})
+		return
+	}
+
+	user.Username = req.Username
+	token, err := middleware.GenerateToken(user, c.GetString("sessionID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Username changed", "username": req.Username, "token": token})
+}
+
+// UpdateProfile replaces the caller's public profile fields. Empty fields
+// are cleared.
+func UpdateProfile(c *gin.Context) {
+	userObjID, _ := primitive.ObjectIDFromHex(c.GetString("userID"))
+
+	var req models.UpdateProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.AvatarURL != "" && !isWebURL(req.AvatarURL, true) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "avatarUrl must be an https URL"})
+		return
+	}
+	for i, link := range req.Links {
+		if !isWebURL(link.URL, false) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Links must be http or https URLs"})
+			return
+		}
+		req.Links[i].Label = strings.TrimSpace(link.Label)
+	}
+
+	set := bson.M{}
+	unset := bson.M{}
+	fields := map[string]interface{}{
+		"display_name":       strings.TrimSpace(req.DisplayName),
+		"bio":                strings.TrimSpace(req.Bio),
+		"avatar_url":         req.AvatarURL,
+		"profile_visibility": req.Visibility,
+	}
+	for field, value := range fields {
+		if value == "" || (field == "profile_visibility" && value == "public") {
+			unset[field] = ""
+		} else {
+			set[field] = value
+		}
+	}
+	if len(req.Links) > 0 {
+		set["links"] = req.Links
+	} else {
+		unset["links"] = ""
+	}
+	update := bson.M{}
+	if len(set) > 0 {
+		update["$set"] = set
+	}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+
+	collection := database.GetCollection("users")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var user models.User
+	err := collection.FindOneAndUpdate(ctx, bson.M{"_id": userObjID}, update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&user)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// GetPublicProfile shows a user's profile. A name the user has since given
+// up redirects to their current one. Private profiles are only visible to
+// their owner.
+func GetPublicProfile(c *gin.Context) {
+	username := c.Param("username")
+
+	collection := database.GetCollection("users")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var user models.User
+	err := collection.FindOne(ctx, bson.M{"username": username}).Decode(&user)
+	if err == mongo.ErrNoDocuments {
+		opts := options.FindOne().SetSort(bson.M{"username_history.changed_at": -1})
+		err = collection.FindOne(ctx, bson.M{"username_history.username": username}, opts).Decode(&user)
+		if err == nil && user.ProfileVisibility != "private" {
+			c.Redirect(http.StatusMovedPermanently, "/api/users/"+url.PathEscape(user.Username))
+			return
+		}
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user"})
+		return
+	}
+	if user.ProfileVisibility == "private" && user.ID.Hex() != c.GetString("userID") {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.PublicProfile{
+		Username:    user.Username,
+		DisplayName: user.DisplayName,
+		Bio:         user.Bio,
+		AvatarURL:   user.AvatarURL,
+		Links:       user.Links,
+		SolvedCount: user.SolvedCount,
+		CreatedAt:   user.CreatedAt,
+	})
+}
+
+// currentUsername reads the user's name from the database for copies stored
+// on comments and lists. The name in an access token may predate a rename
+// made from another session.
+func currentUsername(ctx context.Context, userID primitive.ObjectID) (string, error) {
+	var user models.User
+	err := database.GetCollection("users").FindOne(ctx, bson.M{"_id": userID},
+		options.FindOne().SetProjection(bson.M{"username": 1})).Decode(&user)
+	return user.Username, err
+}
+
+// usernameTaken reports whether another user has username now or gave it up
+// within the hold period. except is the user asking, who may take back their
+// own old names.
+func usernameTaken(ctx context.Context, username string, except primitive.ObjectID) (bool, error) {
+	count, err := database.GetCollection("users").CountDocuments(ctx, bson.M{
+		"_id": bson.M{"$ne": except},
+		"$or": bson.A{
+			bson.M{"username": username},
+			bson.M{"username_history": bson.M{"$elemMatch": bson.M{
+				"username":   username,
+				"changed_at": bson.M{"$gt": time.Now().Add(-usernameHoldPeriod)},
+			}}},
+		},
+	})
+	return count > 0, err
+}
+
+// duplicateKeyOn reports whether err is a duplicate key error from the named
+// index, for collections with more than one unique index.
+func duplicateKeyOn(err error, index string) bool {
+	var we mongo.WriteException
+	if !errors.As(err, &we) {
+		return false
+	}
+	for _, e := range we.WriteErrors {
+		if e.Code == 11000 && strings.Contains(e.Message, "index: "+index+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+func isWebURL(raw string, httpsOnly bool) bool {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	return u.Scheme == "https" || (!httpsOnly && u.Scheme == "http")
+}
@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"errors"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestDuplicateKeyOn(t *testing.T) {
+	dup := func(index string) error {
+		return mongo.WriteException{WriteErrors: mongo.WriteErrors{{
+			Code:    11000,
+			Message: "E11000 duplicate key error collection: woohoodsa.users index: " + index + " dup key: { username: \"ada\" }",
+		}}}
+	}
+
+	if !duplicateKeyOn(dup("username_1"), "username_1") {
+		t.Error("username_1 duplicate not detected")
+	}
+	if duplicateKeyOn(dup("email_1"), "username_1") {
+		t.Error("email_1 duplicate reported as username_1")
+	}
+	if duplicateKeyOn(errors.New("index: username_1 "), "username_1") {
+		t.Error("non-write error reported as duplicate")
+	}
+	if duplicateKeyOn(nil, "username_1") {
+		t.Error("nil reported as duplicate")
+	}
+}
@@ -18,24 +18,49 @@ func GetProgress(c *gin.Context) {
 	userID := c.GetString("userID")
 	userObjID, _ := primitive.ObjectIDFromHex(userID)
 
+	params, err := parseListParams(c, progressSorts, "recent", 50)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	collection := database.GetCollection("progress")
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	cursor, err := collection.Find(ctx, bson.M{"user_id": userObjID})
+	filter := bson.M{"user_id": userObjID}
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch progress"})
+		return
+	}
+
+	cursor, err := collection.Find(ctx, params.withSeek(filter), params.findOptions())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch progress"})
 		return
 	}
 	defer cursor.Close(ctx)
 
-	var progressList []models.Progress
-	if err := cursor.All(ctx, &progressList); err != nil {
+	progressList, next, err := collectPage[models.Progress](ctx, cursor, params)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse progress"})
 		return
 	}
+	for i := range progressList {
+		progressList[i].Status = progressList[i].EffectiveStatus()
+	}
+
+	writeList(c, models.ListResponse[models.Progress]{
+		Items: progressList,
+		Total: total,
+		Next:  next,
+	})
+}
 
-	c.JSON(http.StatusOK, progressList)
+var progressSorts = map[string]bson.D{
+	"recent": {{Key: "updated_at", Value: -1}},
+	"oldest": {{Key: "updated_at", Value: 1}},
 }
 
 func GetProblemProgress(c *gin.Context) {
@@ -43,9 +68,9 @@ func GetProblemProgress(c *gin.Context) {
 	problemID := c.Param("problemId")
 
 	userObjID, _ := primitive.ObjectIDFromHex(userID)
-	problemObjID, err := primitive.ObjectIDFromHex(problemID)
+	problemObjID, err := resolveProblemID(problemID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid problem ID"})
+		problemLookupFailed(c, err)
 		return
 	}
 
@@ -71,6 +96,7 @@ func GetProblemProgress(c *gin.Context) {
 		return
 	}
 
+	progress.Status = progress.EffectiveStatus()
 	c.JSON(http.StatusOK, progress)
 }
 
@@ -85,9 +111,9 @@ func UpdateNotes(c *gin.Context) {
 	}
 
 	userObjID, _ := primitive.ObjectIDFromHex(userID)
-	problemObjID, err := primitive.ObjectIDFromHex(problemID)
+	problemObjID, err := resolveProblemID(problemID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid problem ID"})
+		problemLookupFailed(c, err)
 		return
 	}
 
@@ -120,3 +146,50 @@ func UpdateNotes(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "Notes updated"})
 }
+
+func UpdateBookmark(c *gin.Context) {
+	userID := c.GetString("userID")
+	problemID := c.Param("problemId")
+
+	var req models.UpdateBookmarkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userObjID, _ := primitive.ObjectIDFromHex(userID)
+	problemObjID, err := resolveProblemID(problemID)
+	if err != nil {
+		problemLookupFailed(c, err)
+		return
+	}
+
+	collection := database.GetCollection("progress")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"user_id":    userObjID,
+		"problem_id": problemObjID,
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"bookmarked": req.Bookmarked,
+			"updated_at": time.Now(),
+		},
+		"$setOnInsert": bson.M{
+			"status":   "unsolved",
+			"attempts": 0,
+		},
+	}
+
+	opts := options.Update().SetUpsert(true)
+	_, err = collection.UpdateOne(ctx, filter, update, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update bookmark"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Bookmark updated"})
+}
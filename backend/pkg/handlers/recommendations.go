@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"woohoodsa/pkg/database"
+	"woohoodsa/pkg/models"
+	"woohoodsa/pkg/services"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func GetRecommendations(c *gin.Context) {
+	userID := c.GetString("userID")
+	userObjID, _ := primitive.ObjectIDFromHex(userID)
+
+	limit := 10
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive integer"})
+			return
+		}
+		limit = min(n, 50)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetProjection(problemListProjection)
+	cursor, err := database.GetCollection("problems").Find(ctx, bson.M{}, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch problems"})
+		return
+	}
+	var problems []models.ProblemListItem
+	if err := cursor.All(ctx, &problems); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse problems"})
+		return
+	}
+
+	cursor, err = database.GetCollection("progress").Find(ctx, bson.M{"user_id": userObjID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch progress"})
+		return
+	}
+	var progressList []models.Progress
+	if err := cursor.All(ctx, &progressList); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse progress"})
+		return
+	}
+
+	c.JSON(http.StatusOK, services.Recommend(problems, progressList, limit))
+}
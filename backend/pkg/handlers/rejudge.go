@@ -0,0 +1,352 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"woohoodsa/pkg/database"
+	"woohoodsa/pkg/models"
+	"woohoodsa/pkg/services"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// A rejudge runs in batches, one per request, because the serverless runtime
+// stops work once the response is sent. The admin (or a cron) keeps calling
+// ContinueRejudgeJob until the job is no longer running.
+const (
+	rejudgeBatchSize   = 20
+	rejudgeBatchBudget = 20 * time.Second // Stop starting new evaluations after this
+	rejudgeLease       = 2 * time.Minute  // Must outlast a batch, including its last evaluation
+	rejudgeStaleAfter  = time.Hour        // Running jobs with no progress for this long are failed
+	rejudgeRetryPasses = 2                // Extra passes over submissions the judge failed on
+)
+
+func RejudgeProblem(c *gin.Context) {
+	problemObjID, err := resolveProblemID(c.Param("id"))
+	if err != nil {
+		problemLookupFailed(c, err)
+		return
+	}
+
+	var req models.RejudgeRequest
+	// An empty body rejudges every submission for the problem
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	filter := models.RejudgeFilter{
+		Verdict: req.Verdict,
+		Since:   req.Since,
+	}
+	if req.UserID != "" {
+		userObjID, err := primitive.ObjectIDFromHex(req.UserID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+			return
+		}
+		filter.UserID = &userObjID
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var problem models.Problem
+	err = database.GetCollection("problems").FindOne(ctx, bson.M{"_id": problemObjID}).Decode(&problem)
+	if err != nil {
+		problemLookupFailed(c, err)
+		return
+	}
+
+	total, err := database.GetCollection("submissions").CountDocuments(ctx, rejudgeQuery(problemObjID, filter))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count submissions"})
+		return
+	}
+
+	adminObjID, _ := primitive.ObjectIDFromHex(c.GetString("userID"))
+	now := time.Now()
+	job := models.RejudgeJob{
+		ID:        primitive.NewObjectID(),
+		ProblemID: problemObjID,
+		Filter:    filter,
+		Status:    "running",
+		Total:     int(total),
+		StartedBy: adminObjID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	_, err = database.GetCollection("rejudge_jobs").InsertOne(ctx, job)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create rejudge job"})
+		return
+	}
+
+	job = runRejudgeBatch(job.ID, problem)
+
+	c.JSON(http.StatusAccepted, job)
+}
+
+// ContinueRejudgeJob runs the next batch of a job. If another request holds
+// the job, it returns the job as it is.
+func ContinueRejudgeJob(c *gin.Context) {
+	job, ok := findRejudgeJob(c)
+	if !ok {
+		return
+	}
+	if job.Status != "running" {
+		c.JSON(http.StatusOK, job)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var problem models.Problem
+	err := database.GetCollection("problems").FindOne(ctx, bson.M{"_id": job.ProblemID}).Decode(&problem)
+	if err == mongo.ErrNoDocuments {
+		finishRejudge(job.ID, "failed", "Problem no longer exists")
+	}
+	if err != nil {
+		problemLookupFailed(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, runRejudgeBatch(job.ID, problem))
+}
+
+func GetRejudgeJob(c *gin.Context) {
+	if job, ok := findRejudgeJob(c); ok {
+		c.JSON(http.StatusOK, job)
+	}
+}
+
+// findRejudgeJob loads the job named in the URL, failing it first if it has
+// stalled. It writes the error response itself.
+func findRejudgeJob(c *gin.Context) (models.RejudgeJob, bool) {
+	var job models.RejudgeJob
+	jobObjID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job ID"})
+		return job, false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("rejudge_jobs")
+	now := time.Now()
+	// Fails the job only if it is still stale, so a batch that just made
+	// progress isn't overridden
+	collection.UpdateOne(ctx, bson.M{
+		"_id":        jobObjID,
+		"status":     "running",
+		"updated_at": bson.M{"$lt": now.Add(-rejudgeStaleAfter)},
+	}, bson.M{"$set": bson.M{
+		"status":      "failed",
+		"error":       "Timed out with no progress",
+		"updated_at":  now,
+		"finished_at": now,
+	}})
+
+	err = collection.FindOne(ctx, bson.M{"_id": jobObjID}).Decode(&job)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Rejudge job not found"})
+		return job, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch rejudge job"})
+		return job, false
+	}
+	return job, true
+}
+
+func rejudgeQuery(problemID primitive.ObjectID, filter models.RejudgeFilter) bson.M {
+	query := bson.M{"problem_id": problemID}
+	if filter.Verdict != "" {
+		query["verdict"] = filter.Verdict
+	}
+	if filter.UserID != nil {
+		query["user_id"] = *filter.UserID
+	}
+	if filter.Since != nil {
+		query["created_at"] = bson.M{"$gte": *filter.Since}
+	}
+	return query
+}
+
+// runRejudgeBatch claims the job and re-evaluates the next batch of matching
+// submissions, rewriting verdicts that changed. Counters and the resume point
+// are saved after every submission, so a batch cut short loses at most one
+// evaluation. Submissions the judge fails on are kept aside and retried in up
+// to rejudgeRetryPasses further passes. Once no submissions are left it
+// rebuilds progress for affected users and the problem's stats; if that
+// fails the job stays running, so the next batch tries again. It returns the
+// job as it stands afterwards.
+//
+// Rejudges always run on the system key: the submitter didn't ask for them
+// and must not pay for them. Trial usage is not charged either.
+func runRejudgeBatch(jobID primitive.ObjectID, problem models.Problem) (job models.RejudgeJob) {
+	ctx, cancel := context.WithTimeout(context.Background(), rejudgeLease)
+	defer cancel()
+
+	jobCollection := database.GetCollection("rejudge_jobs")
+	submissionCollection := database.GetCollection("submissions")
+
+	now := time.Now()
+	err := jobCollection.FindOneAndUpdate(ctx, bson.M{
+		"_id":    jobID,
+		"status": "running",
+		"$or": bson.A{
+			bson.M{"lease_until": bson.M{"$exists": false}},
+			bson.M{"lease_until": bson.M{"$lt": now}},
+		},
+	}, bson.M{"$set": bson.M{"lease_until": now.Add(rejudgeLease), "updated_at": now}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&job)
+	if err != nil {
+		// Finished, held by another request, or gone
+		jobCollection.FindOne(ctx, bson.M{"_id": jobID}).Decode(&job)
+		return job
+	}
+	defer func() {
+		jobCollection.UpdateOne(context.Background(), bson.M{"_id": jobID}, bson.M{"$unset": bson.M{"lease_until": ""}})
+		jobCollection.FindOne(context.Background(), bson.M{"_id": jobID}).Decode(&job)
+	}()
+
+	// The first pass walks every matching submission; retry passes only
+	// the ones that failed
+	retrying := job.RetryPass > 0
+	query := rejudgeQuery(job.ProblemID, job.Filter)
+	ids := bson.M{}
+	if retrying {
+		ids["$in"] = job.FailedSubmissions
+	}
+	if job.LastSubmissionID != nil {
+		ids["$gt"] = *job.LastSubmissionID
+	}
+	if len(ids) > 0 {
+		query["_id"] = ids
+	}
+	opts := options.Find().SetSort(bson.M{"_id": 1}).SetLimit(rejudgeBatchSize)
+	submissions, err := findAllDocuments[models.Submission](ctx, "submissions", query, opts)
+	if err != nil {
+		log.Printf("Rejudge %s: failed to fetch submissions: %v", jobID.Hex(), err)
+		return job
+	}
+
+	for _, submission := range submissions {
+		if time.Since(now) > rejudgeBatchBudget {
+			return job
+		}
+
+		update := bson.M{"$set": bson.M{"last_submission_id": submission.ID, "updated_at": time.Now()}}
+		inc := bson.M{}
+		if !retrying {
+			inc["processed"] = 1
+		}
+		result, err := services.EvaluateCode(problem, submission.Code, "")
+		if err == nil && (result.Verdict != submission.Verdict || result.Feedback != submission.Feedback) {
+			_, err = submissionCollection.UpdateOne(ctx, bson.M{"_id": submission.ID}, bson.M{
+				"$set": bson.M{
+					"verdict":     result.Verdict,
+					"feedback":    result.Feedback,
+					"rejudged_at": time.Now(),
+				},
+			})
+			if err == nil && result.Verdict != submission.Verdict {
+				inc["changed"] = 1
+				update["$addToSet"] = bson.M{"affected_users": submission.UserID}
+			}
+		}
+		switch {
+		case err != nil && !retrying:
+			log.Printf("Rejudge %s: failed to rejudge submission %s: %v", jobID.Hex(), submission.ID.Hex(), err)
+			inc["failed"] = 1
+			update["$addToSet"] = bson.M{"failed_submissions": submission.ID}
+		case err != nil:
+			log.Printf("Rejudge %s: retry of submission %s failed again: %v", jobID.Hex(), submission.ID.Hex(), err)
+		case retrying:
+			inc["failed"] = -1
+			update["$pull"] = bson.M{"failed_submissions": submission.ID}
+		}
+		if len(inc) > 0 {
+			update["$inc"] = inc
+		}
+		if _, err := jobCollection.UpdateOne(ctx, bson.M{"_id": jobID}, update); err != nil {
+			log.Printf("Rejudge %s: failed to save progress: %v", jobID.Hex(), err)
+			return job
+		}
+	}
+	if len(submissions) == rejudgeBatchSize {
+		return job
+	}
+
+	// Nothing left in this pass; reload for the final counters and user set
+	if err := jobCollection.FindOne(ctx, bson.M{"_id": jobID}).Decode(&job); err != nil {
+		return job
+	}
+	if len(job.FailedSubmissions) > 0 && job.RetryPass < rejudgeRetryPasses {
+		_, err := jobCollection.UpdateOne(ctx, bson.M{"_id": jobID}, bson.M{
+			"$inc":   bson.M{"retry_pass": 1},
+			"$unset": bson.M{"last_submission_id": ""},
+			"$set":   bson.M{"updated_at": time.Now()},
+		})
+		if err != nil {
+			log.Printf("Rejudge %s: failed to start retry pass: %v", jobID.Hex(), err)
+		}
+		return job
+	}
+
+	// Rebuilding is idempotent, so on failure the job is left running and the
+	// next batch, finding no submissions left, simply tries again
+	recomputeFailed := func(msg string, err error) models.RejudgeJob {
+		log.Printf("Rejudge %s: %s: %v", jobID.Hex(), msg, err)
+		jobCollection.UpdateOne(ctx, bson.M{"_id": jobID}, bson.M{
+			"$set": bson.M{"error": msg + ", run the job again to retry", "updated_at": time.Now()},
+		})
+		return job
+	}
+	for _, userID := range job.AffectedUsers {
+		if err := recomputeProgress(ctx, userID, job.ProblemID); err != nil {
+			return recomputeFailed("Failed to recompute progress", err)
+		}
+	}
+	if job.Changed > 0 {
+		if err := recomputeProblemStats(ctx, job.ProblemID); err != nil {
+			return recomputeFailed("Failed to recompute problem stats", err)
+		}
+	}
+	if job.Failed > 0 {
+		finishRejudge(jobID, "completed_with_errors",
+			fmt.Sprintf("%d submissions could not be rejudged and keep their old verdicts", job.Failed))
+		return job
+	}
+	finishRejudge(jobID, "completed", "")
+	return job
+}
+
+func finishRejudge(jobID primitive.ObjectID, status, errMsg string) {
+	now := time.Now()
+	_, err := database.GetCollection("rejudge_jobs").UpdateOne(context.Background(), bson.M{"_id": jobID}, bson.M{
+		"$set": bson.M{
+			"status":      status,
+			"error":       errMsg,
+			"updated_at":  now,
+			"finished_at": now,
+		},
+	})
+	if err != nil {
+		log.Printf("Rejudge %s: failed to mark job %s: %v", jobID.Hex(), status, err)
+	}
+}
@@ -0,0 +1,430 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"woohoodsa/pkg/database"
+	"woohoodsa/pkg/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// UpdateProblemRelations replaces a problem's prerequisites and similar
+// problems. Prerequisites are rejected if they would create a cycle; similar
+// links are mirrored onto the other problems so the relation stays symmetric.
+func UpdateProblemRelations(c *gin.Context) {
+	problemObjID, err := resolveProblemID(c.Param("id"))
+	if err != nil {
+		problemLookupFailed(c, err)
+		return
+	}
+
+	var req models.ProblemRelationsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	prerequisites, ok := resolveListProblems(c, req.Prerequisites)
+	if !ok {
+		return
+	}
+	similar, ok := resolveListProblems(c, req.Similar)
+	if !ok {
+		return
+	}
+	for _, id := range append(append([]primitive.ObjectID{}, prerequisites...), similar...) {
+		if id == problemObjID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "A problem cannot be related to itself"})
+			return
+		}
+	}
+	// Prerequisites keep the order given, which is the order to study them
+	// in. Similar links are mirrored, so they are kept in ID order and read
+	// back the same whichever side set them.
+	sortObjectIDs(similar)
+
+	collection := database.GetCollection("problems")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// The cycle check reads and the writes, including the mirrored similar
+	// links, run in one transaction so neither a concurrent edit nor a
+	// failure halfway can leave a cycle or a one-sided relation behind
+	session, err := database.Client.StartSession()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update relations"})
+		return
+	}
+	defer session.EndSession(ctx)
+
+	var problem models.Problem
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		if err := collection.FindOne(sc, bson.M{"_id": problemObjID}).Decode(&problem); err != nil {
+			return nil, err
+		}
+
+		graph, err := prerequisiteGraph(sc, bson.M{})
+		if err != nil {
+			return nil, err
+		}
+		graph[problemObjID] = prerequisites
+		if cycle := findCycle(graph); cycle != nil {
+			return nil, prerequisiteCycle(cycle)
+		}
+
+		keep := map[primitive.ObjectID]bool{}
+		for _, id := range similar {
+			keep[id] = true
+		}
+		var removed []primitive.ObjectID
+		for _, id := range problem.Similar {
+			if !keep[id] {
+				removed = append(removed, id)
+			}
+		}
+
+		_, err = collection.UpdateOne(sc, bson.M{"_id": problemObjID}, bson.M{
+			"$set": bson.M{
+				"prerequisites": prerequisites,
+				"similar":       similar,
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(similar) > 0 {
+			// Only problems not yet linked, so this adds like $addToSet while
+			// keeping their edges sorted
+			_, err = collection.UpdateMany(sc, bson.M{
+				"_id":     bson.M{"$in": similar},
+				"similar": bson.M{"$ne": problemObjID},
+			}, bson.M{
+				"$push": bson.M{"similar": bson.M{"$each": bson.A{problemObjID}, "$sort": 1}},
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+		if len(removed) > 0 {
+			_, err = collection.UpdateMany(sc, bson.M{"_id": bson.M{"$in": removed}}, bson.M{
+				"$pull": bson.M{"similar": problemObjID},
+			})
+		}
+		return nil, err
+	})
+	var cycle prerequisiteCycle
+	if errors.As(err, &cycle) {
+		titles, _ := problemTitles(ctx, cycle)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Prerequisites would form a cycle: " + strings.Join(titles, " -> "),
+			"cycle": []primitive.ObjectID(cycle),
+		})
+		return
+	}
+	if err == mongo.ErrNoDocuments {
+		problemLookupFailed(c, err)
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to update relations of problem %s: %v", problemObjID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update relations"})
+		return
+	}
+
+	problem.Prerequisites = prerequisites
+	problem.Similar = similar
+	c.JSON(http.StatusOK, gin.H{
+		"id":            problem.ID,
+		"prerequisites": problem.Prerequisites,
+		"similar":       problem.Similar,
+	})
+}
+
+// GetTopicGraph returns a topic's problems and the prerequisite edges between
+// them for a roadmap view. Prerequisites from other topics are included as
+// nodes so every edge has both ends.
+func GetTopicGraph(c *gin.Context) {
+	topic := c.Param("topic")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	graph, err := prerequisiteGraph(ctx, bson.M{"topic": topic})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load prerequisites"})
+		return
+	}
+	if len(graph) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Topic not found"})
+		return
+	}
+
+	ids := []primitive.ObjectID{}
+	seen := map[primitive.ObjectID]bool{}
+	edges := []models.GraphEdge{}
+	for id, prerequisites := range graph {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+		for _, prerequisite := range prerequisites {
+			edges = append(edges, models.GraphEdge{From: prerequisite, To: id})
+			if !seen[prerequisite] {
+				seen[prerequisite] = true
+				ids = append(ids, prerequisite)
+			}
+		}
+	}
+
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].To != edges[j].To {
+			return edges[i].To.Hex() < edges[j].To.Hex()
+		}
+		return edges[i].From.Hex() < edges[j].From.Hex()
+	})
+
+	nodes, err := fetchProblemItems(ctx, ids, bson.D{
+		{Key: "topic_sequence", Value: 1},
+		{Key: "title", Value: 1},
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch problems"})
+		return
+	}
+
+	if userObjID, err := primitive.ObjectIDFromHex(c.GetString("userID")); err == nil {
+		if _, err := listProgress(ctx, userObjID, nodes); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch progress"})
+			return
+		}
+	}
+
+	order := make([]primitive.ObjectID, len(nodes))
+	for i, node := range nodes {
+		order[i] = node.ID
+	}
+
+	c.JSON(http.StatusOK, models.TopicGraph{
+		Topic: topic,
+		Nodes: nodes,
+		Edges: edges,
+		Order: topoOrder(order, graph),
+	})
+}
+
+// attachRelated fills in problem.Related, annotated with the caller's status
+// when the request is authenticated.
+func attachRelated(ctx context.Context, c *gin.Context, problem *models.Problem) error {
+	if len(problem.Prerequisites) == 0 && len(problem.Similar) == 0 {
+		return nil
+	}
+
+	related := &models.RelatedProblems{}
+	var err error
+	if related.Prerequisites, err = fetchProblemItems(ctx, problem.Prerequisites, nil); err != nil {
+		return err
+	}
+	if related.Similar, err = fetchProblemItems(ctx, problem.Similar, nil); err != nil {
+		return err
+	}
+
+	if userObjID, err := primitive.ObjectIDFromHex(c.GetString("userID")); err == nil {
+		all := append(append([]models.ProblemListItem{}, related.Prerequisites...), related.Similar...)
+		if _, err := listProgress(ctx, userObjID, all); err != nil {
+			return err
+		}
+		copy(related.Prerequisites, all[:len(related.Prerequisites)])
+		copy(related.Similar, all[len(related.Prerequisites):])
+	}
+
+	problem.Related = related
+	return nil
+}
+
+// fetchProblemItems loads list items for ids. With a nil sort they come back
+// in the order of ids; problems that no longer exist are skipped.
+func fetchProblemItems(ctx context.Context, ids []primitive.ObjectID, sort bson.D) ([]models.ProblemListItem, error) {
+	items := []models.ProblemListItem{}
+	if len(ids) == 0 {
+		return items, nil
+	}
+
+	opts := options.Find().SetProjection(problemListProjection)
+	if sort != nil {
+		opts.SetSort(sort)
+	}
+	cursor, err := database.GetCollection("problems").Find(ctx, bson.M{"_id": bson.M{"$in": ids}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var problems []models.ProblemListItem
+	if err := cursor.All(ctx, &problems); err != nil {
+		return nil, err
+	}
+	if sort != nil {
+		return append(items, problems...), nil
+	}
+
+	byID := map[primitive.ObjectID]models.ProblemListItem{}
+	for _, p := range problems {
+		byID[p.ID] = p
+	}
+	for _, id := range ids {
+		if p, ok := byID[id]; ok {
+			items = append(items, p)
+		}
+	}
+	return items, nil
+}
+
+// prerequisiteGraph maps each matching problem to its prerequisites.
+func prerequisiteGraph(ctx context.Context, filter bson.M) (map[primitive.ObjectID][]primitive.ObjectID, error) {
+	opts := options.Find().SetProjection(bson.M{"_id": 1, "prerequisites": 1})
+	cursor, err := database.GetCollection("problems").Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	graph := map[primitive.ObjectID][]primitive.ObjectID{}
+	for cursor.Next(ctx) {
+		var problem models.Problem
+		if err := cursor.Decode(&problem); err != nil {
+			return nil, err
+		}
+		graph[problem.ID] = problem.Prerequisites
+	}
+	return graph, cursor.Err()
+}
+
+// prerequisiteCycle is the cycle an update would create, returned from the
+// transaction in UpdateProblemRelations.
+type prerequisiteCycle []primitive.ObjectID
+
+func (c prerequisiteCycle) Error() string {
+	return "prerequisites would form a cycle"
+}
+
+// findCycle returns the problems along one prerequisite cycle, starting and
+// ending on the same problem, or nil if the graph is a DAG.
+func findCycle(graph map[primitive.ObjectID][]primitive.ObjectID) []primitive.ObjectID {
+	const (
+		unvisited = iota
+		inProgress
+		done
+	)
+	state := map[primitive.ObjectID]int{}
+	var stack []primitive.ObjectID
+
+	var visit func(id primitive.ObjectID) []primitive.ObjectID
+	visit = func(id primitive.ObjectID) []primitive.ObjectID {
+		state[id] = inProgress
+		stack = append(stack, id)
+		for _, next := range graph[id] {
+			switch state[next] {
+			case inProgress:
+				for i, s := range stack {
+					if s == next {
+						return append(append([]primitive.ObjectID{}, stack[i:]...), next)
+					}
+				}
+			case unvisited:
+				if cycle := visit(next); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[id] = done
+		return nil
+	}
+
+	for id := range graph {
+		if state[id] == unvisited {
+			if cycle := visit(id); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
+
+// topoOrder sorts nodes so every prerequisite comes before the problems that
+// need it. Among problems that are ready at the same time the input order
+// (topic_sequence) is kept.
+func topoOrder(nodes []primitive.ObjectID, graph map[primitive.ObjectID][]primitive.ObjectID) []primitive.ObjectID {
+	inNodes := map[primitive.ObjectID]bool{}
+	for _, id := range nodes {
+		inNodes[id] = true
+	}
+
+	pending := map[primitive.ObjectID]int{}
+	dependents := map[primitive.ObjectID][]primitive.ObjectID{}
+	for _, id := range nodes {
+		for _, prerequisite := range graph[id] {
+			if inNodes[prerequisite] {
+				pending[id]++
+				dependents[prerequisite] = append(dependents[prerequisite], id)
+			}
+		}
+	}
+
+	order := make([]primitive.ObjectID, 0, len(nodes))
+	placed := map[primitive.ObjectID]bool{}
+	for len(order) < len(nodes) {
+		progressed := false
+		for _, id := range nodes {
+			if placed[id] || pending[id] > 0 {
+				continue
+			}
+			placed[id] = true
+			order = append(order, id)
+			for _, dependent := range dependents[id] {
+				pending[dependent]--
+			}
+			progressed = true
+			break
+		}
+		if !progressed {
+			// Only reachable if a cycle slipped past validation
+			break
+		}
+	}
+	return order
+}
+
+func problemTitles(ctx context.Context, ids []primitive.ObjectID) ([]string, error) {
+	items, err := fetchProblemItems(ctx, ids, nil)
+	if err != nil {
+		return nil, err
+	}
+	byID := map[primitive.ObjectID]string{}
+	for _, item := range items {
+		byID[item.ID] = item.Title
+	}
+	titles := make([]string, len(ids))
+	for i, id := range ids {
+		titles[i] = byID[id]
+		if titles[i] == "" {
+			titles[i] = id.Hex()
+		}
+	}
+	return titles, nil
+}
+
+func sortObjectIDs(ids []primitive.ObjectID) {
+	sort.Slice(ids, func(i, j int) bool { return ids[i].Hex() < ids[j].Hex() })
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"woohoodsa/pkg/config"
+	"woohoodsa/pkg/database"
+	"woohoodsa/pkg/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// revealLevels are unlocked strictly in this order. A level's position + 1
+// is the value stored in progress.reveal_level.
+var revealLevels = []string{"hint1", "hint2", "solution"}
+
+func RevealHint(c *gin.Context) {
+	userID := c.GetString("userID")
+	userObjID, _ := primitive.ObjectIDFromHex(userID)
+
+	var req models.RevealRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	problemObjID, err := resolveProblemID(c.Param("id"))
+	if err != nil {
+		problemLookupFailed(c, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var problem models.Problem
+	err = database.GetCollection("problems").FindOne(ctx, bson.M{"_id": problemObjID}).Decode(&problem)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Problem not found"})
+		return
+	}
+
+	progressCollection := database.GetCollection("progress")
+	filter := bson.M{
+		"user_id":    userObjID,
+		"problem_id": problemObjID,
+	}
+
+	// No progress document yet just means nothing has been revealed
+	var progress models.Progress
+	progressCollection.FindOne(ctx, filter).Decode(&progress)
+
+	level := 0
+	for i, name := range revealLevels {
+		if name == req.Level {
+			level = i + 1
+		}
+	}
+
+	if level > progress.RevealLevel+1 {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": fmt.Sprintf("Reveal %s first", revealLevels[progress.RevealLevel]),
+			"code":  "REVEAL_OUT_OF_ORDER",
+		})
+		return
+	}
+
+	if level == len(revealLevels) && progress.RevealLevel < level && progress.Status != "solved" {
+		required := config.AppConfig.SolutionUnlockAttempts
+		failed := progress.Attempts - progress.SuccessfulSubmissions
+		if failed < required {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":     fmt.Sprintf("Make %d more attempt(s) before viewing the solution", required-failed),
+				"code":      "SOLUTION_LOCKED",
+				"remaining": required - failed,
+			})
+			return
+		}
+	}
+
+	if level > progress.RevealLevel {
+		now := time.Now()
+		update := bson.M{
+			"$max": bson.M{"reveal_level": level},
+			"$push": bson.M{"reveals": models.Reveal{
+				Level:      req.Level,
+				RevealedAt: now,
+			}},
+			"$set": bson.M{"updated_at": now},
+			"$setOnInsert": bson.M{
+				"status":   "unsolved",
+				"attempts": 0,
+			},
+		}
+		opts := options.Update().SetUpsert(true)
+		if _, err := progressCollection.UpdateOne(ctx, filter, update, opts); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record reveal"})
+			return
+		}
+		progress.RevealLevel = level
+	}
+
+	hintBrute, hintOptimized := localizedHints(problem, requestLocale(ctx, c))
+	response := models.RevealResponse{RevealLevel: progress.RevealLevel}
+	if progress.RevealLevel >= 1 {
+		response.HintBrute = hintBrute
+	}
+	if progress.RevealLevel >= 2 {
+		response.HintOptimized = hintOptimized
+	}
+	if progress.RevealLevel >= 3 {
+		editorial, err := loadEditorial(ctx, problemObjID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch editorial"})
+			return
+		}
+		response.Editorial = editorial
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"woohoodsa/pkg/database"
+	"woohoodsa/pkg/middleware"
+	"woohoodsa/pkg/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// GrantRole gives a user a role, replacing the one they had. Roles are read
+// from the database on every request that checks one, so the change applies
+// at once, including to access tokens already issued.
+func GrantRole(c *gin.Context) {
+	var req models.UpdateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !middleware.IsValidRole(req.Role) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown role: " + req.Role, "roles": models.Roles})
+		return
+	}
+	setRole(c, req.Role, req.Reason, "role.grant")
+}
+
+// RevokeRole returns a user to the plain user role.
+func RevokeRole(c *gin.Context) {
+	setRole(c, models.RoleUser, c.Query("reason"), "role.revoke")
+}
+
+func setRole(c *gin.Context, role, reason, action string) {
+	userObjID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+	// Keeps the last admin from locking everyone out by accident
+	if userObjID.Hex() == c.GetString("userID") && role != models.RoleAdmin {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You can't remove your own admin role"})
+		return
+	}
+
+	update := bson.M{"$set": bson.M{"role": role}}
+	if role == models.RoleUser {
+		update = bson.M{"$unset": bson.M{"role": ""}}
+	}
+
+	collection := database.GetCollection("users")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// The document from before the update says what the role was
+	var before models.User
+	err = collection.FindOneAndUpdate(ctx, bson.M{"_id": userObjID}, update,
+		options.FindOneAndUpdate().SetProjection(bson.M{"role": 1, "username": 1}),
+	).Decode(&before)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if before.EffectiveRole() != role {
+		details := map[string]string{"from": before.EffectiveRole(), "to": role, "username": before.Username}
+		if reason = strings.TrimSpace(reason); reason != "" {
+			details["reason"] = reason
+		}
+		recordAudit(ctx, c, action, "user", userObjID, details)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Role updated", "role": role})
+}
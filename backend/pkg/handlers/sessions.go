@@ -0,0 +1,246 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"woohoodsa/pkg/config"
+	"woohoodsa/pkg/database"
+	"woohoodsa/pkg/middleware"
+	"woohoodsa/pkg/models"
+	"woohoodsa/pkg/services"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// maxPreviousHashes bounds how many rotated-out refresh tokens a session
+// remembers for reuse detection.
+const maxPreviousHashes = 50
+
+// RefreshSession trades a refresh token for a new access token and a new
+// refresh token. The old refresh token stops working; if it is ever presented
+// again the session is revoked, since either the client or an attacker is
+// holding a stolen copy.
+func RefreshSession(c *gin.Context) {
+	var req models.RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	refreshToken, newHash, err := services.NewOpaqueToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+
+	collection := database.GetCollection("sessions")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	oldHash := services.HashToken(req.RefreshToken)
+	var session models.Session
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err = collection.FindOneAndUpdate(ctx, bson.M{
+		"token_hash": oldHash,
+		"revoked_at": bson.M{"$exists": false},
+		"expires_at": bson.M{"$gt": now},
+	}, bson.M{
+		"$set": bson.M{
+			"token_hash":   newHash,
+			"last_used_at": now,
+			"expires_at":   now.Add(config.AppConfig.RefreshTokenTTL),
+			"ip":           c.ClientIP(),
+			"user_agent":   c.Request.UserAgent(),
+		},
+		"$push": bson.M{"previous_hashes": bson.M{
+			"$each":  bson.A{oldHash},
+			"$slice": -maxPreviousHashes,
+		}},
+	}, opts).Decode(&session)
+	if err == mongo.ErrNoDocuments {
+		result, err := collection.UpdateOne(ctx, bson.M{
+			"previous_hashes": oldHash,
+			"revoked_at":      bson.M{"$exists": false},
+		}, bson.M{"$set": bson.M{"revoked_at": now, "revoked_reason": "reuse"}})
+		if err == nil && result.ModifiedCount > 0 {
+			log.Printf("Refresh token reuse from %s; session revoked", c.ClientIP())
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Refresh token was already used; session revoked",
+				"code":  "TOKEN_REUSED",
+			})
+			return
+		}
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired refresh token"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh session"})
+		return
+	}
+
+	var user models.User
+	err = database.GetCollection("users").FindOne(ctx, bson.M{"_id": session.UserID}).Decode(&user)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	token, err := middleware.GenerateToken(user, session.ID.Hex())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.AuthResponse{
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(config.AppConfig.AccessTokenTTL.Seconds()),
+		User:         user,
+	})
+}
+
+// Logout revokes the session the refresh token belongs to. Unknown tokens
+// are not an error, so logging out twice is harmless.
+func Logout(c *gin.Context) {
+	var req models.RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	collection := database.GetCollection("sessions")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := collection.UpdateOne(ctx, bson.M{
+		"token_hash": services.HashToken(req.RefreshToken),
+		"revoked_at": bson.M{"$exists": false},
+	}, bson.M{"$set": bson.M{"revoked_at": time.Now(), "revoked_reason": "logout"}})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out"})
+}
+
+func GetSessions(c *gin.Context) {
+	userObjID, _ := primitive.ObjectIDFromHex(c.GetString("userID"))
+
+	collection := database.GetCollection("sessions")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.M{"last_used_at": -1})
+	cursor, err := collection.Find(ctx, bson.M{
+		"user_id":    userObjID,
+		"revoked_at": bson.M{"$exists": false},
+		"expires_at": bson.M{"$gt": time.Now()},
+	}, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch sessions"})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	sessions := []models.Session{}
+	if err := cursor.All(ctx, &sessions); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse sessions"})
+		return
+	}
+
+	current := c.GetString("sessionID")
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID.Hex() == current
+	}
+
+	c.JSON(http.StatusOK, sessions)
+}
+
+// RevokeSession signs one of the caller's devices out. It can no longer
+// refresh, and its access token is refused as soon as the auth middleware
+// looks the session up again: at once on instances that haven't seen it,
+// within sessionCacheTTL (30s) on those that have.
+func RevokeSession(c *gin.Context) {
+	userObjID, _ := primitive.ObjectIDFromHex(c.GetString("userID"))
+	sessionObjID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+		return
+	}
+
+	collection := database.GetCollection("sessions")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, err := collection.UpdateOne(ctx, bson.M{
+		"_id":        sessionObjID,
+		"user_id":    userObjID,
+		"revoked_at": bson.M{"$exists": false},
+	}, bson.M{"$set": bson.M{"revoked_at": time.Now(), "revoked_reason": "revoked"}})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session"})
+		return
+	}
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Session revoked"})
+}
+
+// startSession records a new device session for user and returns the
+// access/refresh token pair to hand back from a successful sign-in.
+func startSession(ctx context.Context, c *gin.Context, user models.User) (models.AuthResponse, error) {
+	refreshToken, hash, err := services.NewOpaqueToken()
+	if err != nil {
+		return models.AuthResponse{}, err
+	}
+
+	now := time.Now()
+	session := models.Session{
+		ID:         primitive.NewObjectID(),
+		UserID:     user.ID,
+		TokenHash:  hash,
+		UserAgent:  c.Request.UserAgent(),
+		IP:         c.ClientIP(),
+		CreatedAt:  now,
+		LastUsedAt: now,
+		ExpiresAt:  now.Add(config.AppConfig.RefreshTokenTTL),
+	}
+	if _, err := database.GetCollection("sessions").InsertOne(ctx, session); err != nil {
+		return models.AuthResponse{}, err
+	}
+
+	token, err := middleware.GenerateToken(user, session.ID.Hex())
+	if err != nil {
+		return models.AuthResponse{}, err
+	}
+
+	return models.AuthResponse{
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(config.AppConfig.AccessTokenTTL.Seconds()),
+		User:         user,
+	}, nil
+}
+
+// revokeUserSessions signs the user out everywhere except keep, which may be
+// primitive.NilObjectID to revoke every session.
+func revokeUserSessions(ctx context.Context, userID, keep primitive.ObjectID, reason string) error {
+	_, err := database.GetCollection("sessions").UpdateMany(ctx, bson.M{
+		"user_id":    userID,
+		"_id":        bson.M{"$ne": keep},
+		"revoked_at": bson.M{"$exists": false},
+	}, bson.M{"$set": bson.M{"revoked_at": time.Now(), "revoked_reason": reason}})
+	return err
+}
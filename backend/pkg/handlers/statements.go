@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"woohoodsa/pkg/database"
+	"woohoodsa/pkg/models"
+	"woohoodsa/pkg/services"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// UpdateProblemStatement replaces a problem's markdown statement, bumps its
+// statement version and stores the fresh rendering.
+func UpdateProblemStatement(c *gin.Context) {
+	problemObjID, err := resolveProblemID(c.Param("id"))
+	if err != nil {
+		problemLookupFailed(c, err)
+		return
+	}
+
+	var req models.StatementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	html, err := services.RenderStatement(req.Description)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to render statement: " + err.Error()})
+		return
+	}
+
+	collection := database.GetCollection("problems")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var problem models.Problem
+	opts := options.FindOneAndUpdate().
+		SetReturnDocument(options.After).
+		SetProjection(bson.M{"hint_brute": 0, "hint_optimized": 0})
+	err = collection.FindOneAndUpdate(ctx, bson.M{"_id": problemObjID}, bson.M{
+		"$set": bson.M{"description": req.Description},
+		"$inc": bson.M{"statement_version": 1},
+	}, opts).Decode(&problem)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Problem not found"})
+		return
+	}
+
+	problem.DescriptionHTML = html
+	problem.RenderedVersion = renderedVersion(problem.StatementVersion)
+	_, err = collection.UpdateOne(ctx, bson.M{
+		"_id":               problem.ID,
+		"statement_version": problem.StatementVersion,
+	}, bson.M{"$set": bson.M{
+		"description_html": problem.DescriptionHTML,
+		"rendered_version": problem.RenderedVersion,
+	}})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save rendered statement"})
+		return
+	}
+
+	c.JSON(http.StatusOK, problem)
+}
+
+// ensureStatementHTML makes sure problem.DescriptionHTML matches the current
+// statement and renderer. Stale or missing renderings, such as problems
+// seeded before statements were rendered, are rebuilt and written back so
+// the work happens once per version.
+func ensureStatementHTML(ctx context.Context, problem *models.Problem) error {
+	version := renderedVersion(problem.StatementVersion)
+	if problem.RenderedVersion == version {
+		return nil
+	}
+
+	html, err := services.RenderStatement(problem.Description)
+	if err != nil {
+		return err
+	}
+	problem.DescriptionHTML = html
+	problem.RenderedVersion = version
+
+	// Guarded on the statement version so a concurrent edit isn't overwritten
+	// with a rendering of the old text
+	filter := bson.M{"_id": problem.ID, "statement_version": problem.StatementVersion}
+	if problem.StatementVersion == 0 {
+		filter["statement_version"] = bson.M{"$in": bson.A{0, nil}}
+	}
+	_, err = database.GetCollection("problems").UpdateOne(ctx, filter, bson.M{"$set": bson.M{
+		"description_html": html,
+		"rendered_version": version,
+	}})
+	return err
+}
+
+func renderedVersion(statementVersion int) string {
+	return fmt.Sprintf("%d/%d", statementVersion, services.StatementRendererVersion)
+}
@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"woohoodsa/pkg/database"
+	"woohoodsa/pkg/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// updateProblemStats folds one new submission into the problem's stats.
+// firstSolveAttempts is non-zero only when this submission is the user's
+// first accepted one, and is the attempt number it landed on.
+func updateProblemStats(ctx context.Context, problemID primitive.ObjectID, verdict string, passed bool, firstSolveAttempts int) {
+	collection := database.GetCollection("problems")
+
+	inc := bson.M{
+		"stats.total_submissions":               1,
+		"stats.verdicts." + verdictKey(verdict): 1,
+	}
+	if passed {
+		inc["stats.accepted_submissions"] = 1
+	}
+	if firstSolveAttempts > 0 {
+		inc["stats.unique_solvers"] = 1
+		inc["stats.attempts_to_solve."+strconv.Itoa(firstSolveAttempts)] = 1
+	}
+
+	// The derived fields can't be expressed as $inc, so recompute them from
+	// the updated counters. A concurrent submission may briefly leave them
+	// one step behind; the next submission corrects it.
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var problem models.Problem
+	err := collection.FindOneAndUpdate(ctx, bson.M{"_id": problemID}, bson.M{"$inc": inc}, opts).Decode(&problem)
+	if err != nil {
+		fmt.Printf("Failed to update problem stats: %v\n", err)
+		return
+	}
+
+	collection.UpdateOne(ctx, bson.M{"_id": problemID}, bson.M{
+		"$set": bson.M{
+			"stats.acceptance_rate": acceptanceRate(problem.Stats),
+			"stats.median_attempts": medianAttempts(problem.Stats.AttemptsToSolve),
+		},
+	})
+}
+
+// recomputeProblemStats rebuilds a problem's stats from scratch by replaying
+// its submissions in order. Used after a rejudge, and to backfill problems
+// that predate stats.
+func recomputeProblemStats(ctx context.Context, problemID primitive.ObjectID) error {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: 1}}).
+		SetProjection(bson.M{"user_id": 1, "verdict": 1})
+	cursor, err := database.GetCollection("submissions").Find(ctx, bson.M{"problem_id": problemID}, opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	stats := models.ProblemStats{
+		AttemptsToSolve: map[string]int{},
+		Verdicts:        map[string]int{},
+	}
+	attempts := map[primitive.ObjectID]int{}
+	solved := map[primitive.ObjectID]bool{}
+
+	for cursor.Next(ctx) {
+		var submission models.Submission
+		if err := cursor.Decode(&submission); err != nil {
+			return err
+		}
+
+		stats.TotalSubmissions++
+		stats.Verdicts[verdictKey(submission.Verdict)]++
+		attempts[submission.UserID]++
+
+		// Same test the judge uses in parseEvaluationResponse
+		if !strings.Contains(strings.ToLower(submission.Verdict), "accepted") {
+			continue
+		}
+		stats.AcceptedSubmissions++
+		if !solved[submission.UserID] {
+			solved[submission.UserID] = true
+			stats.UniqueSolvers++
+			stats.AttemptsToSolve[strconv.Itoa(attempts[submission.UserID])]++
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	stats.AcceptanceRate = acceptanceRate(stats)
+	stats.MedianAttempts = medianAttempts(stats.AttemptsToSolve)
+
+	_, err = database.GetCollection("problems").UpdateOne(ctx, bson.M{"_id": problemID}, bson.M{
+		"$set": bson.M{"stats": stats},
+	})
+	return err
+}
+
+func RecomputeProblemStats(c *gin.Context) {
+	problemObjID, err := resolveProblemID(c.Param("id"))
+	if err != nil {
+		problemLookupFailed(c, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	if err := recomputeProblemStats(ctx, problemObjID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to recompute stats"})
+		return
+	}
+
+	var problem models.Problem
+	err = database.GetCollection("problems").FindOne(ctx, bson.M{"_id": problemObjID}).Decode(&problem)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Problem not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, problem.Stats)
+}
+
+func acceptanceRate(stats models.ProblemStats) float64 {
+	if stats.TotalSubmissions == 0 {
+		return 0
+	}
+	return float64(stats.AcceptedSubmissions) / float64(stats.TotalSubmissions)
+}
+
+// medianAttempts reads the median out of an attempts histogram, averaging
+// the two middle values when the number of solvers is even.
+func medianAttempts(histogram map[string]int) float64 {
+	type bucket struct{ attempts, solvers int }
+	var buckets []bucket
+	total := 0
+	for key, solvers := range histogram {
+		attempts, err := strconv.Atoi(key)
+		if err != nil || solvers <= 0 {
+			continue
+		}
+		buckets = append(buckets, bucket{attempts, solvers})
+		total += solvers
+	}
+	if total == 0 {
+		return 0
+	}
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i].attempts < buckets[j].attempts })
+
+	// nth returns the attempts value at 0-based rank n
+	nth := func(n int) int {
+		for _, b := range buckets {
+			if n < b.solvers {
+				return b.attempts
+			}
+			n -= b.solvers
+		}
+		return buckets[len(buckets)-1].attempts
+	}
+
+	if total%2 == 1 {
+		return float64(nth(total / 2))
+	}
+	return float64(nth(total/2-1)+nth(total/2)) / 2
+}
+
+// verdictKey makes a judge verdict safe to use as a Mongo field name.
+func verdictKey(verdict string) string {
+	verdict = strings.TrimSpace(strings.NewReplacer(".", "", "$", "").Replace(verdict))
+	if verdict == "" {
+		return "Unknown"
+	}
+	return verdict
+}
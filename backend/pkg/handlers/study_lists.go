@@ -0,0 +1,485 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"woohoodsa/pkg/database"
+	"woohoodsa/pkg/middleware"
+	"woohoodsa/pkg/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var studyListSorts = map[string]bson.D{
+	"newest":  {{Key: "created_at", Value: -1}},
+	"popular": {{Key: "followers", Value: -1}, {Key: "created_at", Value: -1}},
+}
+
+func GetStudyLists(c *gin.Context) {
+	userObjID, authErr := primitive.ObjectIDFromHex(c.GetString("userID"))
+
+	params, err := parseListParams(c, studyListSorts, "newest", 20)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	collection := database.GetCollection("study_lists")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Anonymous callers see public lists; signed-in callers also see their own
+	filter := bson.M{"visibility": "public"}
+	if authErr == nil {
+		filter = bson.M{"$or": bson.A{
+			bson.M{"visibility": "public"},
+			bson.M{"owner_id": userObjID},
+		}}
+	}
+
+	mine := c.Query("mine") == "true"
+	following := c.Query("following") == "true"
+	if (mine || following) && authErr != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Sign in to see your lists"})
+		return
+	}
+	if mine {
+		filter = bson.M{"owner_id": userObjID}
+	}
+	if following {
+		listIDs, err := database.GetCollection("list_follows").Distinct(ctx, "list_id", bson.M{"user_id": userObjID})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch lists"})
+			return
+		}
+		filter = bson.M{"$and": bson.A{filter, bson.M{"_id": bson.M{"$in": listIDs}}}}
+	}
+
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch lists"})
+		return
+	}
+
+	cursor, err := collection.Find(ctx, params.withSeek(filter), params.findOptions())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch lists"})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	lists, next, err := collectPage[models.StudyList](ctx, cursor, params)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse lists"})
+		return
+	}
+
+	writeList(c, models.ListResponse[models.StudyList]{
+		Items: lists,
+		Total: total,
+		Next:  next,
+	})
+}
+
+func GetStudyList(c *gin.Context) {
+	userObjID, authErr := primitive.ObjectIDFromHex(c.GetString("userID"))
+
+	listObjID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid list ID"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var list models.StudyList
+	err = database.GetCollection("study_lists").FindOne(ctx, bson.M{"_id": listObjID}).Decode(&list)
+	if err != nil || (list.Visibility != "public" && (authErr != nil || list.OwnerID != userObjID)) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "List not found"})
+		return
+	}
+
+	detail := models.StudyListDetail{StudyList: list}
+
+	// Keep the list's own order; problems deleted since are skipped
+	detail.Problems, err = fetchProblemItems(ctx, list.ProblemIDs, nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch problems"})
+		return
+	}
+
+	if authErr == nil {
+		progress, err := listProgress(ctx, userObjID, detail.Problems)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch progress"})
+			return
+		}
+		detail.Progress = progress
+
+		count, _ := database.GetCollection("list_follows").CountDocuments(ctx, bson.M{
+			"user_id": userObjID,
+			"list_id": listObjID,
+		})
+		detail.Following = count > 0
+	}
+
+	c.JSON(http.StatusOK, detail)
+}
+
+// listProgress annotates problems in place with the user's status and
+// returns the totals for the list.
+func listProgress(ctx context.Context, userID primitive.ObjectID, problems []models.ProblemListItem) (*models.ListProgress, error) {
+	ids := make([]primitive.ObjectID, len(problems))
+	for i, p := range problems {
+		ids[i] = p.ID
+	}
+
+	cursor, err := database.GetCollection("progress").Find(ctx, bson.M{
+		"user_id":    userID,
+		"problem_id": bson.M{"$in": ids},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var progressList []models.Progress
+	if err := cursor.All(ctx, &progressList); err != nil {
+		return nil, err
+	}
+	byProblem := map[primitive.ObjectID]models.Progress{}
+	for _, p := range progressList {
+		byProblem[p.ProblemID] = p
+	}
+
+	result := &models.ListProgress{Total: len(problems)}
+	for i := range problems {
+		progress := byProblem[problems[i].ID]
+		problems[i].Status = progress.EffectiveStatus()
+		problems[i].Attempts = progress.Attempts
+		problems[i].Bookmarked = progress.Bookmarked
+
+		switch problems[i].Status {
+		case "solved":
+			result.Solved++
+		case "attempted":
+			result.Attempted++
+		}
+	}
+	if result.Total > 0 {
+		result.Percent = float64(result.Solved) / float64(result.Total) * 100
+	}
+	return result, nil
+}
+
+func CreateStudyList(c *gin.Context) {
+	userID := c.GetString("userID")
+	userObjID, _ := primitive.ObjectIDFromHex(userID)
+
+	var req models.StudyListRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	problemIDs, ok := resolveListProblems(c, req.ProblemIDs)
+	if !ok {
+		return
+	}
+
+	visibility := req.Visibility
+	if visibility == "" {
+		visibility = "private"
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	username, err := currentUsername(ctx, userObjID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	now := time.Now()
+	list := models.StudyList{
+		ID:            primitive.NewObjectID(),
+		Name:          req.Name,
+		Description:   req.Description,
+		OwnerID:       userObjID,
+		OwnerUsername: username,
+		Visibility:    visibility,
+		ProblemIDs:    problemIDs,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	_, err = database.GetCollection("study_lists").InsertOne(ctx, list)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create list"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, list)
+}
+
+func UpdateStudyList(c *gin.Context) {
+	list, ok := ownedStudyList(c)
+	if !ok {
+		return
+	}
+
+	var req models.StudyListRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	problemIDs, ok := resolveListProblems(c, req.ProblemIDs)
+	if !ok {
+		return
+	}
+
+	list.Name = req.Name
+	list.Description = req.Description
+	list.ProblemIDs = problemIDs
+	list.UpdatedAt = time.Now()
+	if req.Visibility != "" {
+		list.Visibility = req.Visibility
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := database.GetCollection("study_lists").UpdateOne(ctx, bson.M{"_id": list.ID}, bson.M{
+		"$set": bson.M{
+			"name":        list.Name,
+			"description": list.Description,
+			"visibility":  list.Visibility,
+			"problem_ids": list.ProblemIDs,
+			"updated_at":  list.UpdatedAt,
+		},
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update list"})
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+func DeleteStudyList(c *gin.Context) {
+	list, ok := ownedStudyList(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := database.GetCollection("study_lists").DeleteOne(ctx, bson.M{"_id": list.ID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete list"})
+		return
+	}
+	database.GetCollection("list_follows").DeleteMany(ctx, bson.M{"list_id": list.ID})
+
+	c.JSON(http.StatusOK, gin.H{"message": "List deleted"})
+}
+
+// CloneStudyList copies a visible list into a new private list owned by the
+// caller, who can then reorder or edit it freely.
+func CloneStudyList(c *gin.Context) {
+	userID := c.GetString("userID")
+	userObjID, _ := primitive.ObjectIDFromHex(userID)
+
+	listObjID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid list ID"})
+		return
+	}
+
+	collection := database.GetCollection("study_lists")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var source models.StudyList
+	err = collection.FindOne(ctx, bson.M{"_id": listObjID}).Decode(&source)
+	if err != nil || (source.Visibility != "public" && source.OwnerID != userObjID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "List not found"})
+		return
+	}
+	username, err := currentUsername(ctx, userObjID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	now := time.Now()
+	clone := models.StudyList{
+		ID:            primitive.NewObjectID(),
+		Name:          source.Name,
+		Description:   source.Description,
+		OwnerID:       userObjID,
+		OwnerUsername: username,
+		Visibility:    "private",
+		ProblemIDs:    source.ProblemIDs,
+		ClonedFrom:    &source.ID,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	_, err = collection.InsertOne(ctx, clone)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to clone list"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, clone)
+}
+
+func FollowStudyList(c *gin.Context) {
+	userID := c.GetString("userID")
+	userObjID, _ := primitive.ObjectIDFromHex(userID)
+
+	listObjID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid list ID"})
+		return
+	}
+
+	collection := database.GetCollection("study_lists")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var list models.StudyList
+	err = collection.FindOne(ctx, bson.M{"_id": listObjID}).Decode(&list)
+	if err != nil || (list.Visibility != "public" && list.OwnerID != userObjID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "List not found"})
+		return
+	}
+
+	_, err = database.GetCollection("list_follows").InsertOne(ctx, models.ListFollow{
+		ID:        primitive.NewObjectID(),
+		UserID:    userObjID,
+		ListID:    listObjID,
+		CreatedAt: time.Now(),
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		c.JSON(http.StatusOK, gin.H{"message": "Already following"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to follow list"})
+		return
+	}
+
+	collection.UpdateOne(ctx, bson.M{"_id": listObjID}, bson.M{"$inc": bson.M{"followers": 1}})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Following list"})
+}
+
+func UnfollowStudyList(c *gin.Context) {
+	userID := c.GetString("userID")
+	userObjID, _ := primitive.ObjectIDFromHex(userID)
+
+	listObjID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid list ID"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, err := database.GetCollection("list_follows").DeleteOne(ctx, bson.M{
+		"user_id": userObjID,
+		"list_id": listObjID,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unfollow list"})
+		return
+	}
+
+	if result.DeletedCount > 0 {
+		database.GetCollection("study_lists").UpdateOne(ctx, bson.M{"_id": listObjID}, bson.M{
+			"$inc": bson.M{"followers": -1},
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Unfollowed list"})
+}
+
+// ownedStudyList loads the list named in the URL and checks the caller may
+// modify it: owners can edit their own lists, admins can edit any.
+func ownedStudyList(c *gin.Context) (models.StudyList, bool) {
+	var list models.StudyList
+
+	userID := c.GetString("userID")
+	userObjID, _ := primitive.ObjectIDFromHex(userID)
+
+	listObjID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid list ID"})
+		return list, false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err = database.GetCollection("study_lists").FindOne(ctx, bson.M{"_id": listObjID}).Decode(&list)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "List not found"})
+		return list, false
+	}
+
+	if list.OwnerID != userObjID && !middleware.IsAdmin(userObjID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "List not found or unauthorized"})
+		return list, false
+	}
+
+	return list, true
+}
+
+// resolveListProblems turns the IDs or slugs from a request into ObjectIDs,
+// dropping duplicates but keeping the first occurrence's position.
+func resolveListProblems(c *gin.Context, ids []string) ([]primitive.ObjectID, bool) {
+	problemIDs := []primitive.ObjectID{}
+	seen := map[primitive.ObjectID]bool{}
+	for _, id := range ids {
+		problemObjID, err := resolveProblemID(id)
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Problem not found: " + id})
+			return nil, false
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check problems"})
+			return nil, false
+		}
+		if !seen[problemObjID] {
+			seen[problemObjID] = true
+			problemIDs = append(problemIDs, problemObjID)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	count, err := database.GetCollection("problems").CountDocuments(ctx, bson.M{"_id": bson.M{"$in": problemIDs}})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check problems"})
+		return nil, false
+	}
+	if int(count) != len(problemIDs) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "One or more problems do not exist"})
+		return nil, false
+	}
+
+	return problemIDs, true
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
@@ -26,9 +27,9 @@ func SubmitCode(c *gin.Context) {
 		return
 	}
 
-	problemObjID, err := primitive.ObjectIDFromHex(req.ProblemID)
+	problemObjID, err := resolveProblemID(req.ProblemID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid problem ID"})
+		problemLookupFailed(c, err)
 		return
 	}
 
@@ -103,7 +104,17 @@ func SubmitCode(c *gin.Context) {
 	}
 
 	// Update progress
-	updateProgress(ctx, userObjID, problemObjID, result.Passed)
+	previous := updateProgress(ctx, userObjID, problemObjID, result.Passed)
+
+	// Update problem stats
+	firstSolveAttempts := 0
+	if result.Passed && (previous == nil || previous.Status != "solved") {
+		firstSolveAttempts = 1
+		if previous != nil {
+			firstSolveAttempts = previous.Attempts + 1
+		}
+	}
+	updateProblemStats(ctx, problemObjID, result.Verdict, result.Passed, firstSolveAttempts)
 
 	// Update user stats if accepted
 	if result.Passed {
@@ -117,7 +128,7 @@ func SubmitCode(c *gin.Context) {
 	})
 }
 
-func updateProgress(ctx context.Context, userID, problemID primitive.ObjectID, passed bool) {
+func updateProgress(ctx context.Context, userID, problemID primitive.ObjectID, passed bool) *models.Progress {
 	collection := database.GetCollection("progress")
 
 	filter := bson.M{
@@ -125,26 +136,30 @@ func updateProgress(ctx context.Context, userID, problemID primitive.ObjectID, p
 		"problem_id": problemID,
 	}
 
-	update := bson.M{
-		"$setOnInsert": bson.M{
-			"status": "attempted",
-		},
-		"$set": bson.M{
-			"last_attempted_at": time.Now(),
-			"updated_at":        time.Now(),
-		},
-		"$inc": bson.M{
-			"attempts": 1,
-		},
+	// A pipeline update, so a failed attempt can move any status but
+	// "solved" to "attempted" in the same write. Reveals, bookmarks and notes
+	// create the document as "unsolved" before the first submission.
+	now := time.Now()
+	set := bson.M{
+		"status":            bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$status", "solved"}}, "solved", "attempted"}},
+		"attempts":          bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$attempts", 0}}, 1}},
+		"last_attempted_at": now,
+		"updated_at":        now,
 	}
-
 	if passed {
-		update["$set"].(bson.M)["status"] = "solved"
-		update["$inc"].(bson.M)["successful_submissions"] = 1
+		set["status"] = "solved"
+		set["successful_submissions"] = bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$successful_submissions", 0}}, 1}}
 	}
-
-	opts := options.Update().SetUpsert(true)
-	collection.UpdateOne(ctx, filter, update, opts)
+	update := mongo.Pipeline{{{Key: "$set", Value: set}}}
+
+	// The pre-update document tells updateProblemStats whether this is the
+	// user's first solve and how many attempts it took
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.Before)
+	var previous models.Progress
+	if err := collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&previous); err != nil {
+		return nil
+	}
+	return &previous
 }
 
 func updateUserStats(ctx context.Context, userID primitive.ObjectID) {
@@ -166,14 +181,83 @@ func updateUserStats(ctx context.Context, userID primitive.ObjectID) {
 	})
 }
 
+// recomputeProgress rebuilds a progress document from the user's stored
+// submissions instead of incrementing it, so it is safe to call after verdicts
+// have been rewritten by a rejudge.
+func recomputeProgress(ctx context.Context, userID, problemID primitive.ObjectID) error {
+	submissionCollection := database.GetCollection("submissions")
+	filter := bson.M{
+		"user_id":    userID,
+		"problem_id": problemID,
+	}
+
+	attempts, err := submissionCollection.CountDocuments(ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	// Matches the judge's own notion of a pass (see parseEvaluationResponse)
+	filter["verdict"] = primitive.Regex{Pattern: "accepted", Options: "i"}
+	accepted, err := submissionCollection.CountDocuments(ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	status := "attempted"
+	if accepted > 0 {
+		status = "solved"
+	}
+
+	collection := database.GetCollection("progress")
+	_, err = collection.UpdateOne(ctx, bson.M{
+		"user_id":    userID,
+		"problem_id": problemID,
+	}, bson.M{
+		"$set": bson.M{
+			"status":                 status,
+			"attempts":               attempts,
+			"successful_submissions": accepted,
+			"updated_at":             time.Now(),
+		},
+	}, options.Update().SetUpsert(true))
+	if err != nil {
+		return err
+	}
+
+	return refreshSolvedCount(ctx, userID)
+}
+
+// refreshSolvedCount is updateUserStats without touching last_solve_date,
+// for callers that are correcting history rather than recording a new solve.
+func refreshSolvedCount(ctx context.Context, userID primitive.ObjectID) error {
+	solvedCount, err := database.GetCollection("progress").CountDocuments(ctx, bson.M{
+		"user_id": userID,
+		"status":  "solved",
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = database.GetCollection("users").UpdateOne(ctx, bson.M{"_id": userID}, bson.M{
+		"$set": bson.M{"solved_count": solvedCount},
+	})
+	return err
+}
+
 func GetSubmissions(c *gin.Context) {
 	userID := c.GetString("userID")
 	problemID := c.Param("problemId")
 
 	userObjID, _ := primitive.ObjectIDFromHex(userID)
-	problemObjID, err := primitive.ObjectIDFromHex(problemID)
+	problemObjID, err := resolveProblemID(problemID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid problem ID"})
+		problemLookupFailed(c, err)
+		return
+	}
+
+	params, err := parseListParams(c, submissionSorts, "newest", 10)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
@@ -181,22 +265,37 @@ func GetSubmissions(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	opts := options.Find().SetSort(bson.M{"created_at": -1}).SetLimit(10)
-	cursor, err := collection.Find(ctx, bson.M{
+	filter := bson.M{
 		"user_id":    userObjID,
 		"problem_id": problemObjID,
-	}, opts)
+	}
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch submissions"})
+		return
+	}
+
+	cursor, err := collection.Find(ctx, params.withSeek(filter), params.findOptions())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch submissions"})
 		return
 	}
 	defer cursor.Close(ctx)
 
-	var submissions []models.Submission
-	if err := cursor.All(ctx, &submissions); err != nil {
+	submissions, next, err := collectPage[models.Submission](ctx, cursor, params)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse submissions"})
 		return
 	}
 
-	c.JSON(http.StatusOK, submissions)
+	writeList(c, models.ListResponse[models.Submission]{
+		Items: submissions,
+		Total: total,
+		Next:  next,
+	})
+}
+
+var submissionSorts = map[string]bson.D{
+	"newest": {{Key: "created_at", Value: -1}},
+	"oldest": {{Key: "created_at", Value: 1}},
 }
@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"woohoodsa/pkg/config"
+	"woohoodsa/pkg/database"
+	"woohoodsa/pkg/models"
+	"woohoodsa/pkg/services"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/text/language"
+)
+
+func GetProblemTranslations(c *gin.Context) {
+	problemObjID, err := resolveProblemID(c.Param("id"))
+	if err != nil {
+		problemLookupFailed(c, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var problem models.Problem
+	opts := options.FindOne().SetProjection(bson.M{"translations": 1})
+	err = database.GetCollection("problems").FindOne(ctx, bson.M{"_id": problemObjID}, opts).Decode(&problem)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Problem not found"})
+		return
+	}
+
+	if problem.Translations == nil {
+		problem.Translations = map[string]models.ProblemTranslation{}
+	}
+	c.JSON(http.StatusOK, problem.Translations)
+}
+
+func UpsertProblemTranslation(c *gin.Context) {
+	locale, ok := translationLocale(c)
+	if !ok {
+		return
+	}
+	problemObjID, err := resolveProblemID(c.Param("id"))
+	if err != nil {
+		problemLookupFailed(c, err)
+		return
+	}
+
+	var req models.TranslationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	html, err := services.RenderStatement(req.Description)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to render statement: " + err.Error()})
+		return
+	}
+
+	translation := models.ProblemTranslation{
+		Title:           req.Title,
+		Description:     req.Description,
+		DescriptionHTML: html,
+		RendererVersion: services.StatementRendererVersion,
+		HintBrute:       req.HintBrute,
+		HintOptimized:   req.HintOptimized,
+		UpdatedAt:       time.Now(),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, err := database.GetCollection("problems").UpdateOne(ctx, bson.M{"_id": problemObjID}, bson.M{
+		"$set": bson.M{"translations." + locale: translation},
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save translation"})
+		return
+	}
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Problem not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, translation)
+}
+
+func DeleteProblemTranslation(c *gin.Context) {
+	locale, ok := translationLocale(c)
+	if !ok {
+		return
+	}
+	problemObjID, err := resolveProblemID(c.Param("id"))
+	if err != nil {
+		problemLookupFailed(c, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	field := "translations." + locale
+	result, err := database.GetCollection("problems").UpdateOne(ctx, bson.M{
+		"_id": problemObjID,
+		field: bson.M{"$exists": true},
+	}, bson.M{"$unset": bson.M{field: ""}})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete translation"})
+		return
+	}
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Translation not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Translation deleted"})
+}
+
+// translationLocale validates the :locale param. The default locale is the
+// problem's own fields, so it can't be stored as a translation.
+func translationLocale(c *gin.Context) (string, bool) {
+	locale := c.Param("locale")
+	if !isSupportedLocale(locale) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported locale: " + locale, "supported": config.AppConfig.SupportedLocales})
+		return "", false
+	}
+	if locale == defaultLocale() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": locale + " is the problem's base text; edit the problem instead"})
+		return "", false
+	}
+	return locale, true
+}
+
+func defaultLocale() string {
+	return config.AppConfig.SupportedLocales[0]
+}
+
+func isSupportedLocale(locale string) bool {
+	for _, supported := range config.AppConfig.SupportedLocales {
+		if locale == supported {
+			return true
+		}
+	}
+	return false
+}
+
+// requestLocale picks the locale to serve: the signed-in user's saved
+// preference, then the best Accept-Language match, then the default.
+func requestLocale(ctx context.Context, c *gin.Context) string {
+	c.Header("Vary", "Accept-Language")
+
+	if userObjID, err := primitive.ObjectIDFromHex(c.GetString("userID")); err == nil {
+		var user models.User
+		opts := options.FindOne().SetProjection(bson.M{"locale": 1})
+		err := database.GetCollection("users").FindOne(ctx, bson.M{"_id": userObjID}, opts).Decode(&user)
+		if err == nil && isSupportedLocale(user.Locale) {
+			return user.Locale
+		}
+	}
+
+	return matchLocale(c.GetHeader("Accept-Language"))
+}
+
+// matchLocale returns the supported locale that best fits an Accept-Language
+// header, so "es-MX" gets "es" and anything unknown gets the default.
+func matchLocale(acceptLanguage string) string {
+	supported := config.AppConfig.SupportedLocales
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return supported[0]
+	}
+
+	available := make([]language.Tag, len(supported))
+	for i, locale := range supported {
+		available[i] = language.Make(locale)
+	}
+	_, index, confidence := language.NewMatcher(available).Match(tags...)
+	if confidence == language.No {
+		return supported[0]
+	}
+	return supported[index]
+}
+
+// localizeProblem swaps the problem's title and statement for the caller's
+// locale when a translation exists. Hints are left alone; they're only served
+// through RevealHint, which localizes them itself.
+func localizeProblem(ctx context.Context, c *gin.Context, problem *models.Problem) error {
+	locale := requestLocale(ctx, c)
+	problem.Locale = defaultLocale()
+
+	translation, ok := problem.Translations[locale]
+	if ok && locale != problem.Locale {
+		if translation.RendererVersion != services.StatementRendererVersion {
+			html, err := services.RenderStatement(translation.Description)
+			if err != nil {
+				return err
+			}
+			translation.DescriptionHTML = html
+			translation.RendererVersion = services.StatementRendererVersion
+			database.GetCollection("problems").UpdateOne(ctx, bson.M{"_id": problem.ID}, bson.M{
+				"$set": bson.M{
+					"translations." + locale + ".description_html": html,
+					"translations." + locale + ".renderer_version": translation.RendererVersion,
+				},
+			})
+		}
+		problem.Title = translation.Title
+		problem.Description = translation.Description
+		problem.DescriptionHTML = translation.DescriptionHTML
+		problem.Locale = locale
+	}
+
+	c.Header("Content-Language", problem.Locale)
+	return nil
+}
+
+// localizedHints returns the problem's hints in locale, falling back to the
+// default text for any hint that hasn't been translated.
+func localizedHints(problem models.Problem, locale string) (string, string) {
+	brute, optimized := problem.HintBrute, problem.HintOptimized
+	if translation, ok := problem.Translations[locale]; ok {
+		if translation.HintBrute != "" {
+			brute = translation.HintBrute
+		}
+		if translation.HintOptimized != "" {
+			optimized = translation.HintOptimized
+		}
+	}
+	return brute, optimized
+}
@@ -0,0 +1,377 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"woohoodsa/pkg/config"
+	"woohoodsa/pkg/database"
+	"woohoodsa/pkg/models"
+	"woohoodsa/pkg/services"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	twoFactorChallengeTTL = 5 * time.Minute
+	maxTwoFactorAttempts  = 5
+	recoveryCodeCount     = 10
+)
+
+// EnrollTwoFactor starts TOTP enrollment with a fresh secret. Nothing changes
+// at login until the user confirms a code from their app with
+// ConfirmTwoFactor.
+func EnrollTwoFactor(c *gin.Context) {
+	userObjID, _ := primitive.ObjectIDFromHex(c.GetString("userID"))
+
+	collection := database.GetCollection("users")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var user models.User
+	if err := collection.FindOne(ctx, bson.M{"_id": userObjID}).Decode(&user); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if twoFactorEnabled(user) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is already enabled"})
+		return
+	}
+
+	secret, err := services.NewTOTPSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start enrollment"})
+		return
+	}
+	_, err = collection.UpdateOne(ctx, bson.M{"_id": userObjID}, bson.M{
+		"$set": bson.M{"two_factor.enabled": false, "two_factor.pending_secret": secret},
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start enrollment"})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.TwoFactorEnrollResponse{
+		Secret:     secret,
+		OtpauthURI: services.TOTPURI(config.AppConfig.TOTPIssuer, user.Username, secret),
+	})
+}
+
+// ConfirmTwoFactor turns 2FA on once the user proves their app has the
+// pending secret, and returns the recovery codes. They are never shown again.
+func ConfirmTwoFactor(c *gin.Context) {
+	userObjID, _ := primitive.ObjectIDFromHex(c.GetString("userID"))
+
+	var req models.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	collection := database.GetCollection("users")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var user models.User
+	if err := collection.FindOne(ctx, bson.M{"_id": userObjID}).Decode(&user); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if twoFactorEnabled(user) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is already enabled"})
+		return
+	}
+	if user.TwoFactor == nil || user.TwoFactor.PendingSecret == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Start enrollment first"})
+		return
+	}
+
+	secret := user.TwoFactor.PendingSecret
+	step, ok := services.ValidateTOTP(secret, req.Code, time.Now())
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid code"})
+		return
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate recovery codes"})
+		return
+	}
+
+	// Matching on the pending secret means a second enrollment started in
+	// the meantime wins instead of being overwritten
+	now := time.Now()
+	result, err := collection.UpdateOne(ctx, bson.M{
+		"_id":                       userObjID,
+		"two_factor.pending_secret": secret,
+	}, bson.M{"$set": bson.M{"two_factor": models.TwoFactor{
+		Enabled:       true,
+		Secret:        secret,
+		RecoveryCodes: hashes,
+		LastStep:      step,
+		EnabledAt:     &now,
+	}}})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enable two-factor authentication"})
+		return
+	}
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Enrollment changed, scan the new code and try again"})
+		return
+	}
+
+	// Other devices signed in with just the password; make them pass 2FA too
+	currentSession, _ := primitive.ObjectIDFromHex(c.GetString("sessionID"))
+	if err := revokeUserSessions(ctx, userObjID, currentSession, "two_factor_enabled"); err != nil {
+		log.Printf("Failed to revoke sessions for user %s: %v", userObjID.Hex(), err)
+	}
+
+	c.JSON(http.StatusOK, models.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// DisableTwoFactor turns 2FA off. It takes an authenticator or recovery code
+// so a stolen access token alone can't strip the second factor.
+func DisableTwoFactor(c *gin.Context) {
+	userObjID, _ := primitive.ObjectIDFromHex(c.GetString("userID"))
+
+	var req models.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	collection := database.GetCollection("users")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var user models.User
+	if err := collection.FindOne(ctx, bson.M{"_id": userObjID}).Decode(&user); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if !twoFactorEnabled(user) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Two-factor authentication is not enabled"})
+		return
+	}
+
+	ok, err := verifySecondFactor(ctx, user, req.Code, true)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify code"})
+		return
+	}
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid code"})
+		return
+	}
+
+	_, err = collection.UpdateOne(ctx, bson.M{"_id": userObjID}, bson.M{"$unset": bson.M{"two_factor": ""}})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to disable two-factor authentication"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+}
+
+// RegenerateRecoveryCodes replaces every recovery code. It needs a code from
+// the authenticator app, not a recovery code.
+func RegenerateRecoveryCodes(c *gin.Context) {
+	userObjID, _ := primitive.ObjectIDFromHex(c.GetString("userID"))
+
+	var req models.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	collection := database.GetCollection("users")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var user models.User
+	if err := collection.FindOne(ctx, bson.M{"_id": userObjID}).Decode(&user); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if !twoFactorEnabled(user) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Two-factor authentication is not enabled"})
+		return
+	}
+
+	ok, err := verifySecondFactor(ctx, user, req.Code, false)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify code"})
+		return
+	}
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid code"})
+		return
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate recovery codes"})
+		return
+	}
+	_, err = collection.UpdateOne(ctx, bson.M{"_id": userObjID}, bson.M{
+		"$set": bson.M{"two_factor.recovery_codes": hashes},
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save recovery codes"})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// LoginTwoFactor is the second step of a login: it trades the challenge
+// token from Login plus an authenticator or recovery code for a session.
+func LoginTwoFactor(c *gin.Context) {
+	var req models.TwoFactorLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens := database.GetCollection("user_tokens")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	challengeFilter := bson.M{
+		"token_hash": services.HashToken(req.ChallengeToken),
+		"purpose":    "login_2fa",
+		"used_at":    bson.M{"$exists": false},
+		"expires_at": bson.M{"$gt": time.Now()},
+		"attempts":   bson.M{"$not": bson.M{"$gte": maxTwoFactorAttempts}},
+	}
+	var challenge models.UserToken
+	if err := tokens.FindOne(ctx, challengeFilter).Decode(&challenge); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Sign-in expired, please log in again"})
+		return
+	}
+
+	var user models.User
+	if err := database.GetCollection("users").FindOne(ctx, bson.M{"_id": challenge.UserID}).Decode(&user); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	// Wrong codes count toward the same lockout as wrong passwords, since
+	// reaching this step means the password was right
+	if remaining := loginLockedFor(ctx, user.Username); remaining > 0 {
+		lockedOut(c, remaining)
+		return
+	}
+
+	ok, err := verifySecondFactor(ctx, user, req.Code, true)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify code"})
+		return
+	}
+	if !ok {
+		tokens.UpdateOne(ctx, bson.M{"_id": challenge.ID}, bson.M{"$inc": bson.M{"attempts": 1}})
+		recordLoginFailure(ctx, user.Username)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid code"})
+		return
+	}
+
+	// Consuming the challenge makes the second step single-use even if two
+	// requests race with valid codes
+	if _, err := consumeUserToken(ctx, req.ChallengeToken, "login_2fa"); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Sign-in expired, please log in again"})
+		return
+	}
+	clearLoginFailures(ctx, user.Username)
+
+	response, err := startSession(ctx, c, user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func twoFactorEnabled(user models.User) bool {
+	return user.TwoFactor != nil && user.TwoFactor.Enabled
+}
+
+// twoFactorChallenge issues the token a client exchanges, with a code, for a
+// session once the password (or OAuth) step has passed.
+func twoFactorChallenge(ctx context.Context, user models.User) (models.TwoFactorChallenge, error) {
+	token, err := issueUserToken(ctx, user, "login_2fa", twoFactorChallengeTTL)
+	if err != nil {
+		return models.TwoFactorChallenge{}, err
+	}
+	return models.TwoFactorChallenge{
+		TwoFactorRequired: true,
+		ChallengeToken:    token,
+		ExpiresIn:         int(twoFactorChallengeTTL.Seconds()),
+	}, nil
+}
+
+// verifySecondFactor checks an authenticator code and, if allowRecovery is
+// set, falls back to a recovery code. Accepting either is recorded atomically:
+// the TOTP step moves forward so the same code can't be used twice, and a
+// recovery code is removed.
+func verifySecondFactor(ctx context.Context, user models.User, code string, allowRecovery bool) (bool, error) {
+	if !twoFactorEnabled(user) {
+		return false, nil
+	}
+	collection := database.GetCollection("users")
+
+	if step, ok := services.ValidateTOTP(user.TwoFactor.Secret, code, time.Now()); ok {
+		result, err := collection.UpdateOne(ctx, bson.M{
+			"_id":                  user.ID,
+			"two_factor.last_step": bson.M{"$lt": step},
+		}, bson.M{"$set": bson.M{"two_factor.last_step": step}})
+		if err != nil {
+			return false, err
+		}
+		return result.ModifiedCount == 1, nil
+	}
+	if !allowRecovery {
+		return false, nil
+	}
+
+	hash := services.HashToken(normalizeRecoveryCode(code))
+	result, err := collection.UpdateOne(ctx, bson.M{
+		"_id":                       user.ID,
+		"two_factor.recovery_codes": hash,
+	}, bson.M{"$pull": bson.M{"two_factor.recovery_codes": hash}})
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+// newRecoveryCodes returns codes formatted for people (xxxx-xxxx-xxxx) and
+// the hashes to store. Each has 60 random bits, so a fast hash is enough.
+func newRecoveryCodes() ([]string, []string, error) {
+	encoding := base32.StdEncoding.WithPadding(base32.NoPadding)
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		buf := make([]byte, 8)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, err
+		}
+		raw := strings.ToLower(encoding.EncodeToString(buf))[:12]
+		codes = append(codes, raw[:4]+"-"+raw[4:8]+"-"+raw[8:])
+		hashes = append(hashes, services.HashToken(raw))
+	}
+	return codes, hashes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"woohoodsa/pkg/database"
+	"woohoodsa/pkg/models"
+	"woohoodsa/pkg/services"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	verifyEmailTTL   = 48 * time.Hour
+	resetPasswordTTL = time.Hour
+)
+
+// issueUserToken replaces any outstanding token of the same purpose for the
+// user and returns the new one in plain text, to be mailed.
+func issueUserToken(ctx context.Context, user models.User, purpose string, ttl time.Duration) (string, error) {
+	token, hash, err := services.NewOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+
+	collection := database.GetCollection("user_tokens")
+	_, err = collection.DeleteMany(ctx, bson.M{"user_id": user.ID, "purpose": purpose, "used_at": bson.M{"$exists": false}})
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	_, err = collection.InsertOne(ctx, models.UserToken{
+		UserID:    user.ID,
+		Purpose:   purpose,
+		TokenHash: hash,
+		Email:     user.Email,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	})
+	return token, err
+}
+
+// consumeUserToken marks a token used and returns it, or mongo.ErrNoDocuments
+// if it is unknown, expired, already used or meant for something else.
+func consumeUserToken(ctx context.Context, token, purpose string) (models.UserToken, error) {
+	now := time.Now()
+	var userToken models.UserToken
+	err := database.GetCollection("user_tokens").FindOneAndUpdate(ctx, bson.M{
+		"token_hash": services.HashToken(token),
+		"purpose":    purpose,
+		"used_at":    bson.M{"$exists": false},
+		"expires_at": bson.M{"$gt": now},
+	}, bson.M{"$set": bson.M{"used_at": now}}, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&userToken)
+	return userToken, err
+}
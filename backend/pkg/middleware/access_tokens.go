@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"woohoodsa/pkg/database"
+	"woohoodsa/pkg/models"
+	"woohoodsa/pkg/services"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// AccessTokenPrefix starts every personal access token, which is how
+// AuthMiddleware tells them from JWTs.
+const AccessTokenPrefix = "wdsa_"
+
+// Scopes a personal access token can be given.
+const (
+	ScopeSubmit       = "submit"        // Submit code and read your own submissions
+	ScopeReadProgress = "read:progress" // Read progress and recommendations
+)
+
+var AccessTokenScopes = []string{ScopeSubmit, ScopeReadProgress}
+
+// TokenRoutes maps "METHOD /route/pattern" to the scope a personal access
+// token needs there; an empty scope means any token will do. Routes not
+// listed refuse personal access tokens, so account settings can't be changed
+// with one. Filled in by SetupServer.
+var TokenRoutes = map[string]string{}
+
+// lastUsedPrecision limits last_used_at writes to one a minute per token.
+const lastUsedPrecision = time.Minute
+
+// authenticateAccessToken checks a personal access token and the route's
+// scope, and sets the same context keys as a JWT. It writes the error
+// response itself.
+func authenticateAccessToken(c *gin.Context, token string) bool {
+	scope, allowed := TokenRoutes[c.Request.Method+" "+c.FullPath()]
+	if !allowed {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Personal access tokens can't be used here"})
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	var accessToken models.AccessToken
+	err := database.GetCollection("access_tokens").FindOne(ctx, bson.M{
+		"token_hash": services.HashToken(strings.TrimPrefix(token, AccessTokenPrefix)),
+		"$or": bson.A{
+			bson.M{"expires_at": bson.M{"$exists": false}},
+			bson.M{"expires_at": bson.M{"$gt": now}},
+		},
+	}).Decode(&accessToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+		return false
+	}
+	if scope != "" && !slices.Contains(accessToken.Scopes, scope) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Token is missing the " + scope + " scope"})
+		return false
+	}
+
+	var user models.User
+	err = database.GetCollection("users").FindOne(ctx, bson.M{"_id": accessToken.UserID},
+		options.FindOne().SetProjection(bson.M{"username": 1, "role": 1}),
+	).Decode(&user)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+		return false
+	}
+
+	if accessToken.LastUsedAt == nil || now.Sub(*accessToken.LastUsedAt) > lastUsedPrecision {
+		database.GetCollection("access_tokens").UpdateOne(ctx, bson.M{"_id": accessToken.ID},
+			bson.M{"$set": bson.M{"last_used_at": now}})
+	}
+
+	c.Set("userID", user.ID.Hex())
+	c.Set("username", user.Username)
+	// Read just now, so Can needn't look it up again
+	c.Set("role", user.EffectiveRole())
+	c.Set("accessTokenID", accessToken.ID.Hex())
+	return true
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"woohoodsa/pkg/database"
+	"woohoodsa/pkg/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AdminMiddleware must run after AuthMiddleware. The role is read from the
+// database rather than the token so that demoting an admin takes effect
+// immediately.
+func AdminMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userObjID, err := primitive.ObjectIDFromHex(c.GetString("userID"))
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user ID"})
+			c.Abort()
+			return
+		}
+
+		if !IsAdmin(userObjID) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// IsAdmin is for handlers that are open to everyone but let admins act on
+// other users' resources.
+func IsAdmin(userID primitive.ObjectID) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var user models.User
+	err := database.GetCollection("users").FindOne(ctx, bson.M{"_id": userID}).Decode(&user)
+	return err == nil && user.Role == models.RoleAdmin
+}
@@ -6,23 +6,30 @@ import (
 	"time"
 
 	"woohoodsa/pkg/config"
+	"woohoodsa/pkg/models"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 )
 
 type Claims struct {
-	UserID   string `json:"user_id"`
-	Username string `json:"username"`
+	UserID    string `json:"user_id"`
+	Username  string `json:"username"`
+	SessionID string `json:"sid,omitempty"`
+	Role      string `json:"role,omitempty"` // As of when the token was issued; permission checks read the database
 	jwt.RegisteredClaims
 }
 
-func GenerateToken(userID, username string) (string, error) {
+// GenerateToken issues a short-lived access token for a session. Sessions are
+// refreshed through POST /api/auth/refresh.
+func GenerateToken(user models.User, sessionID string) (string, error) {
 	claims := &Claims{
-		UserID:   userID,
-		Username: username,
+		UserID:    user.ID.Hex(),
+		Username:  user.Username,
+		SessionID: sessionID,
+		Role:      user.EffectiveRole(),
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * 7 * time.Hour)), // 7 days
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(config.AppConfig.AccessTokenTTL)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
@@ -31,6 +38,8 @@ func GenerateToken(userID, username string) (string, error) {
 	return token.SignedString([]byte(config.AppConfig.JWTSecret))
 }
 
+// AuthMiddleware accepts a session access token (JWT) or, on the routes in
+// TokenRoutes, a personal access token.
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
@@ -47,19 +56,60 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		claims := &Claims{}
-		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-			return []byte(config.AppConfig.JWTSecret), nil
-		})
+		if strings.HasPrefix(tokenString, AccessTokenPrefix) {
+			if !authenticateAccessToken(c, tokenString) {
+				c.Abort()
+				return
+			}
+			c.Next()
+			return
+		}
 
-		if err != nil || !token.Valid {
+		claims, err := ParseToken(tokenString)
+		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
 			c.Abort()
 			return
 		}
+		if !sessionActive(claims.SessionID, claims.UserID) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Session has been signed out"})
+			c.Abort()
+			return
+		}
 
 		c.Set("userID", claims.UserID)
 		c.Set("username", claims.Username)
+		c.Set("sessionID", claims.SessionID)
 		c.Next()
 	}
 }
+
+// OptionalAuthMiddleware is for public routes that personalise their response
+// when the caller is logged in. A missing or invalid token is not an error;
+// the request just proceeds without a userID.
+func OptionalAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenString := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if tokenString != "" {
+			if claims, err := ParseToken(tokenString); err == nil && sessionActive(claims.SessionID, claims.UserID) {
+				c.Set("userID", claims.UserID)
+				c.Set("username", claims.Username)
+			}
+		}
+		c.Next()
+	}
+}
+
+func ParseToken(tokenString string) (*Claims, error) {
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return []byte(config.AppConfig.JWTSecret), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, jwt.ErrTokenInvalidClaims
+	}
+	return claims, nil
+}
@@ -0,0 +1,302 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"woohoodsa/pkg/config"
+	"woohoodsa/pkg/database"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Limit is a token bucket holding up to Requests tokens that refills at
+// Requests per Window. A full bucket allows a burst of Requests at once.
+type Limit struct {
+	Requests int
+	Window   time.Duration
+}
+
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / l.Window.Seconds()
+}
+
+// RateLimitResult is the outcome of taking a token from a bucket.
+type RateLimitResult struct {
+	Allowed    bool
+	Remaining  int           // Whole tokens left after this request
+	RetryAfter time.Duration // Until the next token, when not allowed
+	Reset      time.Duration // Until the bucket is full again
+}
+
+// RateLimitStore holds the buckets. Take removes a token from the bucket at
+// key, creating it full if it doesn't exist.
+type RateLimitStore interface {
+	Take(ctx context.Context, key string, limit Limit, now time.Time) (RateLimitResult, error)
+}
+
+// RateLimits is the store picked by InitRateLimitStore from
+// config.RateLimitStore.
+var RateLimits RateLimitStore = NewMemoryStore()
+
+// InitRateLimitStore picks the bucket store. The in-memory store is per
+// process, so deployments running several instances (or serverless) should
+// use "mongo" to share the buckets.
+func InitRateLimitStore() error {
+	switch config.AppConfig.RateLimitStore {
+	case "memory", "":
+		RateLimits = NewMemoryStore()
+	case "mongo":
+		RateLimits = &MongoStore{Collection: "rate_limits"}
+	default:
+		return fmt.Errorf("unknown RATE_LIMIT_STORE %q", config.AppConfig.RateLimitStore)
+	}
+	return nil
+}
+
+// Counters by limiter name, published through expvar and the admin metrics
+// endpoint. They are per process.
+var (
+	RateLimitRejections  = expvar.NewMap("rate_limit_rejections")
+	RateLimitStoreErrors = expvar.NewMap("rate_limit_store_errors")
+)
+
+// RouteLimit is the per-user limiter for a route, declared where the route
+// is registered. The default can be overridden with RATE_LIMITS, e.g.
+// RATE_LIMITS="submit=20/1m,comments=0" (0 turns a limiter off). It must run
+// after AuthMiddleware; anonymous callers are limited by IP.
+func RouteLimit(name string, defaultLimit Limit) gin.HandlerFunc {
+	limit := defaultLimit
+	if override, ok := config.AppConfig.RateLimitOverrides[name]; ok {
+		parsed, err := ParseLimit(override)
+		if err != nil {
+			log.Printf("Ignoring RATE_LIMITS entry for %s: %v", name, err)
+		} else {
+			limit = parsed
+		}
+	}
+	return RateLimit(name, limit, ByUser)
+}
+
+// ParseLimit reads a limit written as requests/window, such as "10/1m".
+// "0" on its own means no limit.
+func ParseLimit(s string) (Limit, error) {
+	requests, window, found := strings.Cut(strings.TrimSpace(s), "/")
+	n, err := strconv.Atoi(requests)
+	if err != nil || n < 0 {
+		return Limit{}, fmt.Errorf("invalid request count in %q", s)
+	}
+	if n == 0 && !found {
+		return Limit{}, nil
+	}
+	d, err := time.ParseDuration(window)
+	if err != nil || d <= 0 {
+		return Limit{}, fmt.Errorf("invalid window in %q", s)
+	}
+	return Limit{Requests: n, Window: d}, nil
+}
+
+// RateLimit limits requests per key. key returns "" for requests it doesn't
+// apply to, and a limit of zero requests turns the limiter off. Requests over
+// the limit get a 429 with Retry-After; if the store fails, requests are let
+// through rather than locking everyone out.
+func RateLimit(name string, limit Limit, key func(c *gin.Context) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if limit.Requests <= 0 {
+			c.Next()
+			return
+		}
+		k := key(c)
+		if k == "" {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		result, err := RateLimits.Take(ctx, name+":"+k, limit, time.Now())
+		if err != nil {
+			log.Printf("Rate limit store failed for %s: %v", name, err)
+			RateLimitStoreErrors.Add(name, 1)
+			c.Next()
+			return
+		}
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(limit.Requests))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+		if !result.Allowed {
+			RateLimitRejections.Add(name, 1)
+			TooManyRequests(c, result.RetryAfter, "Too many requests, please try again later")
+			return
+		}
+		c.Next()
+	}
+}
+
+// TooManyRequests aborts with a 429 telling the client when to retry.
+func TooManyRequests(c *gin.Context, retryAfter time.Duration, message string) {
+	seconds := max(ceilSeconds(retryAfter), 1)
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+		"error":      message,
+		"retryAfter": seconds,
+	})
+}
+
+// ByIP keys a limit on the client address.
+func ByIP(c *gin.Context) string {
+	return c.ClientIP()
+}
+
+// ByUser keys a limit on the signed-in user, falling back to the client
+// address.
+func ByUser(c *gin.Context) string {
+	if userID := c.GetString("userID"); userID != "" {
+		return "user:" + userID
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// ByJSONField keys a limit on a string field of the JSON body, such as the
+// username on login. The body is put back for the handler to bind.
+func ByJSONField(field string) func(c *gin.Context) string {
+	return func(c *gin.Context) string {
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, 1<<20))
+		if err != nil {
+			return ""
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		var fields map[string]any
+		if json.Unmarshal(body, &fields) != nil {
+			return ""
+		}
+		value, _ := fields[field].(string)
+		return strings.ToLower(strings.TrimSpace(value))
+	}
+}
+
+// MemoryStore keeps buckets in process memory.
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*memoryBucket
+	takes   int
+}
+
+type memoryBucket struct {
+	tokens  float64
+	updated time.Time
+	full    time.Time // When the bucket will have refilled; safe to drop after
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: map[string]*memoryBucket{}}
+}
+
+func (s *MemoryStore) Take(_ context.Context, key string, limit Limit, now time.Time) (RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Every so often drop buckets that have refilled; a new full bucket
+	// behaves the same
+	s.takes++
+	if s.takes%1000 == 0 {
+		for k, b := range s.buckets {
+			if now.After(b.full) {
+				delete(s.buckets, k)
+			}
+		}
+	}
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &memoryBucket{tokens: float64(limit.Requests), updated: now}
+		s.buckets[key] = b
+	}
+	b.tokens = math.Min(float64(limit.Requests), b.tokens+now.Sub(b.updated).Seconds()*limit.rate())
+	b.updated = now
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	result := bucketResult(allowed, b.tokens, limit)
+	b.full = now.Add(result.Reset)
+	return result, nil
+}
+
+// MongoStore keeps buckets in a collection so every instance shares them.
+// Each take is a single atomic pipeline update; expires_at lets a TTL index
+// clean up buckets that have refilled.
+type MongoStore struct {
+	Collection string
+}
+
+func (s *MongoStore) Take(ctx context.Context, key string, limit Limit, now time.Time) (RateLimitResult, error) {
+	burst := float64(limit.Requests)
+	elapsed := bson.M{"$divide": bson.A{
+		bson.M{"$subtract": bson.A{now, bson.M{"$ifNull": bson.A{"$updated_at", now}}}},
+		1000,
+	}}
+	pipeline := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{"tokens": bson.M{"$min": bson.A{burst, bson.M{"$add": bson.A{
+			bson.M{"$ifNull": bson.A{"$tokens", burst}},
+			bson.M{"$multiply": bson.A{elapsed, limit.rate()}},
+		}}}}}}},
+		{{Key: "$set", Value: bson.M{"allowed": bson.M{"$gte": bson.A{"$tokens", 1}}}}},
+		{{Key: "$set", Value: bson.M{
+			"tokens":     bson.M{"$cond": bson.A{"$allowed", bson.M{"$subtract": bson.A{"$tokens", 1}}, "$tokens"}},
+			"updated_at": now,
+			"expires_at": now.Add(limit.Window),
+		}}},
+	}
+
+	var bucket struct {
+		Tokens  float64 `bson:"tokens"`
+		Allowed bool    `bson:"allowed"`
+	}
+	collection := database.GetCollection(s.Collection)
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	err := collection.FindOneAndUpdate(ctx, bson.M{"_id": key}, pipeline, opts).Decode(&bucket)
+	if mongo.IsDuplicateKeyError(err) {
+		// Two first requests raced to create the bucket; it exists now
+		err = collection.FindOneAndUpdate(ctx, bson.M{"_id": key}, pipeline, opts).Decode(&bucket)
+	}
+	if err != nil {
+		return RateLimitResult{}, err
+	}
+	return bucketResult(bucket.Allowed, bucket.Tokens, limit), nil
+}
+
+func bucketResult(allowed bool, tokens float64, limit Limit) RateLimitResult {
+	rate := limit.rate()
+	result := RateLimitResult{
+		Allowed:   allowed,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     time.Duration((float64(limit.Requests) - tokens) / rate * float64(time.Second)),
+	}
+	if !allowed {
+		result.RetryAfter = time.Duration((1 - tokens) / rate * float64(time.Second))
+	}
+	return result
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMemoryStoreTokenBucket(t *testing.T) {
+	store := NewMemoryStore()
+	limit := Limit{Requests: 3, Window: 3 * time.Second} // One token a second
+	ctx := context.Background()
+	start := time.Unix(1_700_000_000, 0)
+
+	for i := 0; i < 3; i++ {
+		result, _ := store.Take(ctx, "k", limit, start)
+		if !result.Allowed || result.Remaining != 2-i {
+			t.Fatalf("take %d = %+v, want allowed with %d left", i, result, 2-i)
+		}
+	}
+
+	result, _ := store.Take(ctx, "k", limit, start)
+	if result.Allowed {
+		t.Fatal("fourth take in a burst of three allowed")
+	}
+	if result.RetryAfter != time.Second {
+		t.Errorf("RetryAfter = %s, want 1s", result.RetryAfter)
+	}
+
+	if result, _ := store.Take(ctx, "other", limit, start); !result.Allowed {
+		t.Error("a different key shares the bucket")
+	}
+
+	result, _ = store.Take(ctx, "k", limit, start.Add(1500*time.Millisecond))
+	if !result.Allowed || result.Remaining != 0 {
+		t.Errorf("take after refill = %+v, want allowed with 0 left", result)
+	}
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	RateLimits = NewMemoryStore()
+
+	r := gin.New()
+	limit := RateLimit("test", Limit{Requests: 1, Window: time.Minute}, ByJSONField("username"))
+	var bound string
+	r.POST("/login", limit, func(c *gin.Context) {
+		var req struct{ Username string }
+		c.ShouldBindJSON(&req)
+		bound = req.Username
+		c.Status(http.StatusOK)
+	})
+
+	post := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(body)))
+		return w
+	}
+
+	first := post(`{"username":"Ada"}`)
+	if first.Code != http.StatusOK || bound != "Ada" {
+		t.Fatalf("first request: status %d, handler saw %q", first.Code, bound)
+	}
+	if got := first.Header().Get("X-RateLimit-Limit") + " " + first.Header().Get("X-RateLimit-Remaining") + " " + first.Header().Get("X-RateLimit-Reset"); got != "1 0 60" {
+		t.Errorf("limit/remaining/reset headers = %q, want \"1 0 60\"", got)
+	}
+	w := post(`{"username":"ada "}`)
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("second request for the same username: status %d, want 429", w.Code)
+	}
+	if w.Header().Get("Retry-After") != "60" {
+		t.Errorf("Retry-After = %q, want 60", w.Header().Get("Retry-After"))
+	}
+	if w := post(`{"username":"grace"}`); w.Code != http.StatusOK {
+		t.Errorf("other username: status %d, want 200", w.Code)
+	}
+}
+
+func TestParseLimit(t *testing.T) {
+	cases := map[string]Limit{
+		"10/1m":  {Requests: 10, Window: time.Minute},
+		" 3/30s": {Requests: 3, Window: 30 * time.Second},
+		"0":      {},
+	}
+	for in, want := range cases {
+		got, err := ParseLimit(in)
+		if err != nil || got != want {
+			t.Errorf("ParseLimit(%q) = %+v, %v; want %+v", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "10", "ten/1m", "10/soon", "10/0s", "-1/1m"} {
+		if _, err := ParseLimit(in); err == nil {
+			t.Errorf("ParseLimit(%q) succeeded", in)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"woohoodsa/pkg/database"
+	"woohoodsa/pkg/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Permission is something a role may be allowed to do.
+type Permission string
+
+const (
+	PermModerateComments Permission = "comments:moderate" // Delete anyone's comment
+	PermManageContent    Permission = "content:manage"    // Edit statements, editorials, translations and relations
+)
+
+// rolePermissions lists what each role may do beyond a regular user. Admins
+// have every permission.
+var rolePermissions = map[string][]Permission{
+	models.RoleModerator:  {PermModerateComments},
+	models.RoleInstructor: {PermManageContent},
+}
+
+func IsValidRole(role string) bool {
+	for _, r := range models.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+func RoleHasPermission(role string, perm Permission) bool {
+	if role == models.RoleAdmin {
+		return true
+	}
+	for _, p := range rolePermissions[role] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// Can reports whether the caller may do perm. Like AdminMiddleware it reads
+// the role from the database, so granting or revoking a role applies to
+// tokens already issued. The role is looked up once per request and kept
+// under the "role" key, which nothing fills from token claims.
+func Can(c *gin.Context, perm Permission) bool {
+	if role, ok := c.Get("role"); ok {
+		return RoleHasPermission(role.(string), perm)
+	}
+
+	role := ""
+	if userObjID, err := primitive.ObjectIDFromHex(c.GetString("userID")); err == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		var user models.User
+		err := database.GetCollection("users").FindOne(ctx, bson.M{"_id": userObjID},
+			options.FindOne().SetProjection(bson.M{"role": 1})).Decode(&user)
+		if err == nil {
+			role = user.EffectiveRole()
+		}
+	}
+	c.Set("role", role)
+	return RoleHasPermission(role, perm)
+}
+
+// RequirePermission must run after AuthMiddleware.
+func RequirePermission(perm Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !Can(c, perm) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Permission required: " + string(perm)})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"woohoodsa/pkg/database"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// sessionCacheTTL is how long a session found live is trusted without
+// another lookup, and so the longest a revoked session's access tokens keep
+// working on an instance that already saw them.
+const sessionCacheTTL = 30 * time.Second
+
+var liveSessions = struct {
+	sync.Mutex
+	until map[string]time.Time
+}{until: map[string]time.Time{}}
+
+// sessionActive reports whether the session behind an access token still
+// exists, belongs to the user and hasn't been revoked or expired. Logout,
+// RevokeSession and the bulk revocations on password reset and 2FA changes
+// mark the session revoked; this is what makes them cut off access tokens
+// already handed out.
+func sessionActive(sessionID, userID string) bool {
+	key := sessionID + ":" + userID
+	now := time.Now()
+
+	liveSessions.Lock()
+	until, ok := liveSessions.until[key]
+	liveSessions.Unlock()
+	if ok && now.Before(until) {
+		return true
+	}
+
+	sessionObjID, err := primitive.ObjectIDFromHex(sessionID)
+	if err != nil {
+		return false
+	}
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	count, err := database.GetCollection("sessions").CountDocuments(ctx, bson.M{
+		"_id":        sessionObjID,
+		"user_id":    userObjID,
+		"revoked_at": bson.M{"$exists": false},
+		"expires_at": bson.M{"$gt": now},
+	})
+	if err != nil {
+		log.Printf("Failed to check session %s: %v", sessionID, err)
+		return false
+	}
+	if count == 0 {
+		return false
+	}
+
+	liveSessions.Lock()
+	// Entries are small, but don't let them pile up forever
+	if len(liveSessions.until) > 10000 {
+		for k, t := range liveSessions.until {
+			if now.After(t) {
+				delete(liveSessions.until, k)
+			}
+		}
+	}
+	liveSessions.until[key] = now.Add(sessionCacheTTL)
+	liveSessions.Unlock()
+	return true
+}
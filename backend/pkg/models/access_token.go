@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AccessToken is a personal access token for scripts and editor plugins. It
+// acts as its user, but only on the routes its scopes allow.
+type AccessToken struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID     primitive.ObjectID `bson:"user_id" json:"-"`
+	Name       string             `bson:"name" json:"name"`
+	Scopes     []string           `bson:"scopes" json:"scopes"`
+	TokenHash  string             `bson:"token_hash" json:"-"`
+	Prefix     string             `bson:"prefix" json:"prefix"` // Start of the token, so users can tell them apart
+	CreatedAt  time.Time          `bson:"created_at" json:"createdAt"`
+	LastUsedAt *time.Time         `bson:"last_used_at,omitempty" json:"lastUsedAt,omitempty"`
+	ExpiresAt  *time.Time         `bson:"expires_at,omitempty" json:"expiresAt,omitempty"` // Nil never expires
+}
+
+type CreateAccessTokenRequest struct {
+	Name          string   `json:"name" binding:"required,max=100"`
+	Scopes        []string `json:"scopes" binding:"required,min=1"`
+	ExpiresInDays int      `json:"expiresInDays" binding:"min=0,max=365"` // 0 for no expiry
+}
+
+// CreateAccessTokenResponse is the only time the token itself is returned.
+type CreateAccessTokenResponse struct {
+	AccessToken
+	Token string `json:"token"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// AccountExport is everything we hold about a user, as handed back by
+// GET /api/account/export.
+type AccountExport struct {
+	ExportedAt    time.Time     `json:"exportedAt"`
+	Profile       User          `json:"profile"`
+	Progress      []Progress    `json:"progress"`
+	Notes         []ExportNote  `json:"notes"`
+	Submissions   []Submission  `json:"submissions"`
+	Comments      []Comment     `json:"comments"`
+	StudyLists    []StudyList   `json:"studyLists"`
+	FollowedLists []ListFollow  `json:"followedLists"`
+	Sessions      []Session     `json:"sessions"`
+	AccessTokens  []AccessToken `json:"accessTokens"`
+}
+
+// ExportNote pulls the free-text notes out of progress records so they are
+// easy to find in an export.
+type ExportNote struct {
+	ProblemID    string    `json:"problemId"`
+	ProblemTitle string    `json:"problemTitle,omitempty"`
+	Notes        string    `json:"notes"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+// DeleteAccountRequest re-confirms who is asking. Accounts with a password
+// give it; accounts that only sign in through a provider type their
+// username instead. A 2FA code is needed when 2FA is on.
+type DeleteAccountRequest struct {
+	Password string `json:"password"`
+	Confirm  string `json:"confirm"`
+	Code     string `json:"code"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AuditEntry records a privileged action, such as a role change or a
+// moderator removing someone else's comment.
+type AuditEntry struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	ActorID       primitive.ObjectID `bson:"actor_id" json:"actorId"`
+	ActorUsername string             `bson:"actor_username" json:"actorUsername"`
+	Action        string             `bson:"action" json:"action"` // e.g. "role.grant", "comment.delete"
+	TargetType    string             `bson:"target_type" json:"targetType"`
+	TargetID      primitive.ObjectID `bson:"target_id" json:"targetId"`
+	Details       map[string]string  `bson:"details,omitempty" json:"details,omitempty"`
+	CreatedAt     time.Time          `bson:"created_at" json:"createdAt"`
+}
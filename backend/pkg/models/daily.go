@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type DailyChallenge struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Date      string             `bson:"date" json:"date"` // YYYY-MM-DD in config.DailyTimezone
+	ProblemID primitive.ObjectID `bson:"problem_id" json:"problemId"`
+	Source    string             `bson:"source" json:"source"` // "scheduled" or "auto"
+	CreatedAt time.Time          `bson:"created_at" json:"createdAt"`
+}
+
+type DailyScheduleEntry struct {
+	Date      string `json:"date" binding:"required"`
+	ProblemID string `json:"problemId" binding:"required"` // ID or slug
+}
+
+type DailyScheduleRequest struct {
+	Entries []DailyScheduleEntry `json:"entries" binding:"required,min=1,dive"`
+}
+
+type DailyResponse struct {
+	Date    string  `json:"date"`
+	Problem Problem `json:"problem"`
+	Solved  bool    `json:"solved"`
+	Status  string  `json:"status,omitempty"` // Caller's progress, only when authenticated
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Approach struct {
+	Name            string            `bson:"name" json:"name" binding:"required,oneof=brute better optimal"`
+	Title           string            `bson:"title" json:"title"`
+	Explanation     string            `bson:"explanation" json:"explanation"` // Markdown
+	TimeComplexity  string            `bson:"time_complexity" json:"timeComplexity"`
+	SpaceComplexity string            `bson:"space_complexity" json:"spaceComplexity"`
+	Code            map[string]string `bson:"code" json:"code"` // Language ("cpp", "python", ...) -> source
+}
+
+// Editorial replaces the old single Problem.BestSolution field. There is at
+// most one per problem.
+type Editorial struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	ProblemID  primitive.ObjectID `bson:"problem_id" json:"problemId"`
+	Approaches []Approach         `bson:"approaches" json:"approaches"`
+	AuthorID   primitive.ObjectID `bson:"author_id,omitempty" json:"authorId,omitempty"`
+	CreatedAt  time.Time          `bson:"created_at" json:"createdAt"`
+	UpdatedAt  time.Time          `bson:"updated_at" json:"updatedAt"`
+}
+
+type EditorialRequest struct {
+	Approaches []Approach `json:"approaches" binding:"required,min=1,dive"`
+}
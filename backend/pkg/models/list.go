@@ -0,0 +1,9 @@
+package models
+
+// ListResponse is the envelope returned by every paginated list endpoint.
+// Next is an opaque token to pass back as ?next= and is empty on the last page.
+type ListResponse[T any] struct {
+	Items []T    `json:"items"`
+	Total int64  `json:"total"`
+	Next  string `json:"next,omitempty"`
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// LoginFailure counts failed logins in a row for a username, whether or not
+// an account with that name exists.
+type LoginFailure struct {
+	Username      string     `bson:"_id"` // Lowercased
+	Failures      int        `bson:"failures"`
+	LockedUntil   *time.Time `bson:"locked_until,omitempty"`
+	LastFailureAt time.Time  `bson:"last_failure_at"`
+	ExpiresAt     time.Time  `bson:"expires_at"` // The count is forgotten after a quiet spell
+}
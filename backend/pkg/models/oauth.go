@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// OAuthState carries an in-flight sign-in between the redirect to the
+// provider and its callback. It is deleted when the callback consumes it.
+type OAuthState struct {
+	ID           primitive.ObjectID  `bson:"_id,omitempty"`
+	StateHash    string              `bson:"state_hash"`
+	Provider     string              `bson:"provider"`
+	CodeVerifier string              `bson:"code_verifier"`          // PKCE secret, never sent to the browser
+	RedirectPath string              `bson:"redirect_path"`          // Frontend path to land on afterwards
+	LinkUserID   *primitive.ObjectID `bson:"link_user_id,omitempty"` // Set when linking to a signed-in user
+	ExpiresAt    time.Time           `bson:"expires_at"`
+}
@@ -12,25 +12,137 @@ type TestCase struct {
 }
 
 type Problem struct {
-	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	Title         string             `bson:"title" json:"title"`
-	Slug          string             `bson:"slug" json:"slug"`
-	Difficulty    string             `bson:"difficulty" json:"difficulty"` // Easy, Medium, Hard
-	Topic         string             `bson:"topic" json:"topic"`
-	Description   string             `bson:"description" json:"description"`
-	StarterCode   string             `bson:"starter_code" json:"starterCode"`
-	TestCases     []TestCase         `bson:"test_cases" json:"testCases"`
-	HintBrute     string             `bson:"hint_brute" json:"hintBrute"`
-	HintOptimized string             `bson:"hint_optimized" json:"hintOptimized"`
-	BestSolution  string             `bson:"best_solution" json:"bestSolution"`
-	CreatedAt     time.Time          `bson:"created_at" json:"createdAt"`
+	ID               primitive.ObjectID            `bson:"_id,omitempty" json:"id"`
+	Title            string                        `bson:"title" json:"title"`
+	Slug             string                        `bson:"slug" json:"slug"`
+	PreviousSlugs    []string                      `bson:"previous_slugs,omitempty" json:"previousSlugs,omitempty"` // Redirect to Slug
+	Difficulty       string                        `bson:"difficulty" json:"difficulty"`                            // Easy, Medium, Hard
+	Topic            string                        `bson:"topic" json:"topic"`
+	Tags             []string                      `bson:"tags,omitempty" json:"tags"`                        // e.g. "two pointers", "sliding window"
+	Companies        []string                      `bson:"companies,omitempty" json:"companies"`              // Companies known to ask it
+	Description      string                        `bson:"description" json:"description"`                    // Markdown with $LaTeX$ and fenced examples
+	DescriptionHTML  string                        `bson:"description_html,omitempty" json:"descriptionHtml"` // Sanitized rendering of Description
+	StatementVersion int                           `bson:"statement_version" json:"statementVersion"`         // Bumped on every statement edit
+	RenderedVersion  string                        `bson:"rendered_version,omitempty" json:"-"`               // Which statement and renderer version DescriptionHTML came from
+	StarterCode      string                        `bson:"starter_code" json:"starterCode"`
+	TestCases        []TestCase                    `bson:"test_cases" json:"testCases"`
+	HintBrute        string                        `bson:"hint_brute" json:"hintBrute,omitempty"` // Gated behind POST /api/problems/:id/reveal
+	HintOptimized    string                        `bson:"hint_optimized" json:"hintOptimized,omitempty"`
+	Stats            ProblemStats                  `bson:"stats" json:"stats"`
+	Prerequisites    []primitive.ObjectID          `bson:"prerequisites,omitempty" json:"prerequisites,omitempty"` // Must form a DAG
+	Similar          []primitive.ObjectID          `bson:"similar,omitempty" json:"similar,omitempty"`             // Kept symmetric
+	Related          *RelatedProblems              `bson:"-" json:"related,omitempty"`
+	Translations     map[string]ProblemTranslation `bson:"translations,omitempty" json:"-"` // Keyed by locale
+	Locale           string                        `bson:"-" json:"locale,omitempty"`       // Locale the text fields are in
+	CreatedAt        time.Time                     `bson:"created_at" json:"createdAt"`
+}
+
+// RelatedProblems resolves Prerequisites and Similar for GetProblem, with the
+// caller's status on each when signed in.
+type RelatedProblems struct {
+	Prerequisites []ProblemListItem `json:"prerequisites"`
+	Similar       []ProblemListItem `json:"similar"`
+}
+
+// ProblemTranslation holds a problem's text in one locale. Empty hints fall
+// back to the default locale's.
+type ProblemTranslation struct {
+	Title           string    `bson:"title" json:"title"`
+	Description     string    `bson:"description" json:"description"` // Markdown, like Problem.Description
+	DescriptionHTML string    `bson:"description_html" json:"descriptionHtml"`
+	RendererVersion int       `bson:"renderer_version" json:"-"`
+	HintBrute       string    `bson:"hint_brute,omitempty" json:"hintBrute,omitempty"`
+	HintOptimized   string    `bson:"hint_optimized,omitempty" json:"hintOptimized,omitempty"`
+	UpdatedAt       time.Time `bson:"updated_at" json:"updatedAt"`
+}
+
+type TranslationRequest struct {
+	Title         string `json:"title" binding:"required"`
+	Description   string `json:"description" binding:"required"`
+	HintBrute     string `json:"hintBrute"`
+	HintOptimized string `json:"hintOptimized"`
+}
+
+type StatementRequest struct {
+	Description string `json:"description" binding:"required"` // Markdown source
+}
+
+type ProblemRelationsRequest struct {
+	Prerequisites []string `json:"prerequisites"` // IDs or slugs
+	Similar       []string `json:"similar"`
+}
+
+type GraphEdge struct {
+	From primitive.ObjectID `json:"from"` // Prerequisite
+	To   primitive.ObjectID `json:"to"`
+}
+
+type TopicGraph struct {
+	Topic string               `json:"topic"`
+	Nodes []ProblemListItem    `json:"nodes"` // Includes prerequisites from other topics
+	Edges []GraphEdge          `json:"edges"`
+	Order []primitive.ObjectID `json:"order"` // A topological order of Nodes
+}
+
+// ProblemStats is maintained incrementally on every submission and rebuilt
+// from the submissions collection after a rejudge.
+type ProblemStats struct {
+	TotalSubmissions    int            `bson:"total_submissions" json:"totalSubmissions"`
+	AcceptedSubmissions int            `bson:"accepted_submissions" json:"acceptedSubmissions"`
+	AcceptanceRate      float64        `bson:"acceptance_rate" json:"acceptanceRate"` // 0..1
+	UniqueSolvers       int            `bson:"unique_solvers" json:"uniqueSolvers"`
+	MedianAttempts      float64        `bson:"median_attempts" json:"medianAttempts"`    // Attempts up to and including the first AC
+	AttemptsToSolve     map[string]int `bson:"attempts_to_solve" json:"attemptsToSolve"` // Attempt count -> number of solvers
+	Verdicts            map[string]int `bson:"verdicts" json:"verdicts"`                 // Verdict -> number of submissions
 }
 
 type ProblemListItem struct {
-	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	Title         string             `bson:"title" json:"title"`
-	Slug          string             `bson:"slug" json:"slug"`
-	Difficulty    string             `bson:"difficulty" json:"difficulty"`
-	Topic         string             `bson:"topic" json:"topic"`
-	TopicSequence int                `bson:"topic_sequence" json:"topicSequence"`
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Title          string             `bson:"title" json:"title"`
+	Slug           string             `bson:"slug" json:"slug"`
+	Difficulty     string             `bson:"difficulty" json:"difficulty"`
+	Topic          string             `bson:"topic" json:"topic"`
+	TopicSequence  int                `bson:"topic_sequence" json:"topicSequence"`
+	Tags           []string           `bson:"tags,omitempty" json:"tags"`
+	Companies      []string           `bson:"companies,omitempty" json:"companies"`
+	AcceptanceRate float64            `bson:"acceptance_rate" json:"acceptanceRate"`
+
+	// Caller's progress, only set when the request is authenticated
+	Status     string `bson:"status,omitempty" json:"status,omitempty"` // "solved", "attempted", "unsolved"
+	Attempts   int    `bson:"attempts,omitempty" json:"attempts,omitempty"`
+	Bookmarked bool   `bson:"bookmarked,omitempty" json:"bookmarked,omitempty"`
+}
+
+type RevealRequest struct {
+	Level string `json:"level" binding:"required,oneof=hint1 hint2 solution"`
+}
+
+// RevealResponse carries everything the caller has unlocked so far, not just
+// the level they asked for.
+type RevealResponse struct {
+	RevealLevel   int        `json:"revealLevel"`
+	HintBrute     string     `json:"hintBrute,omitempty"`
+	HintOptimized string     `json:"hintOptimized,omitempty"`
+	Editorial     *Editorial `json:"editorial,omitempty"`
+}
+
+type RenameSlugRequest struct {
+	Slug string `json:"slug" binding:"required"`
+}
+
+type FacetCount struct {
+	Value string `bson:"_id" json:"value"`
+	Count int    `bson:"count" json:"count"`
+}
+
+type ProblemFacets struct {
+	Topics       []FacetCount `bson:"topics" json:"topics"`
+	Difficulties []FacetCount `bson:"difficulties" json:"difficulties"`
+	Tags         []FacetCount `bson:"tags" json:"tags"`
+	Companies    []FacetCount `bson:"companies" json:"companies"`
+}
+
+type ProblemListResponse struct {
+	ListResponse[ProblemListItem]
+	Facets ProblemFacets `json:"facets"`
 }
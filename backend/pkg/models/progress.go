@@ -10,16 +10,43 @@ type Progress struct {
 	ID                    primitive.ObjectID `bson:"_id,omitempty" json:"id"`
 	UserID                primitive.ObjectID `bson:"user_id" json:"userId"`
 	ProblemID             primitive.ObjectID `bson:"problem_id" json:"problemId"`
-	Status                string             `bson:"status" json:"status"`     // "attempted", "solved"
+	Status                string             `bson:"status" json:"status"`     // "unsolved", "attempted", "solved"; read through EffectiveStatus
 	Code                  string             `bson:"code" json:"code"`         // Last submitted code
 	Language              string             `bson:"language" json:"language"` // "cpp", "python", etc.
 	Attempts              int                `bson:"attempts" json:"attempts"`
 	SuccessfulSubmissions int                `bson:"successful_submissions" json:"successfulSubmissions"`
 	Notes                 string             `bson:"notes" json:"notes"`
+	Bookmarked            bool               `bson:"bookmarked" json:"bookmarked"`
+	RevealLevel           int                `bson:"reveal_level" json:"revealLevel"` // 0 none, 1 hint1, 2 hint2, 3 solution
+	Reveals               []Reveal           `bson:"reveals,omitempty" json:"reveals,omitempty"`
 	UpdatedAt             time.Time          `bson:"updated_at" json:"updatedAt"`
 	LastAttemptedAt       time.Time          `bson:"last_attempted_at" json:"lastAttemptedAt"`
 }
 
+// EffectiveStatus is the status every view shows: "unsolved" when there is
+// none, and "attempted" for a problem with attempts but no solve, which a
+// bookmark, note or reveal made before the first submission could leave
+// stored as "unsolved".
+func (p Progress) EffectiveStatus() string {
+	switch {
+	case p.Status == "solved":
+		return "solved"
+	case p.Status == "attempted" || p.Attempts > 0:
+		return "attempted"
+	default:
+		return "unsolved"
+	}
+}
+
+type Reveal struct {
+	Level      string    `bson:"level" json:"level"` // "hint1", "hint2", "solution"
+	RevealedAt time.Time `bson:"revealed_at" json:"revealedAt"`
+}
+
 type UpdateNotesRequest struct {
 	Notes string `json:"notes"`
 }
+
+type UpdateBookmarkRequest struct {
+	Bookmarked bool `json:"bookmarked"`
+}
@@ -0,0 +1,7 @@
+package models
+
+type Recommendation struct {
+	Problem ProblemListItem `json:"problem"`
+	Score   float64         `json:"score"`
+	Reasons []string        `json:"reasons"` // e.g. "Weak in Graphs", "Next in Arrays sequence"
+}
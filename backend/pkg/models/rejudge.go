@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type RejudgeFilter struct {
+	Verdict string              `bson:"verdict,omitempty" json:"verdict,omitempty"`
+	UserID  *primitive.ObjectID `bson:"user_id,omitempty" json:"userId,omitempty"`
+	Since   *time.Time          `bson:"since,omitempty" json:"since,omitempty"`
+}
+
+type RejudgeJob struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	ProblemID primitive.ObjectID `bson:"problem_id" json:"problemId"`
+	Filter    RejudgeFilter      `bson:"filter" json:"filter"`
+	Status    string             `bson:"status" json:"status"` // "running", "completed", "completed_with_errors", "failed"
+	Total     int                `bson:"total" json:"total"`
+	Processed int                `bson:"processed" json:"processed"`
+	Changed   int                `bson:"changed" json:"changed"` // Submissions whose verdict flipped
+	Failed    int                `bson:"failed" json:"failed"`   // Submissions the judge could not evaluate, even on retry
+	Error     string             `bson:"error,omitempty" json:"error,omitempty"`
+	// Batches resume after LastSubmissionID; LeaseUntil keeps two requests
+	// from working on the job at once
+	LastSubmissionID *primitive.ObjectID  `bson:"last_submission_id,omitempty" json:"-"`
+	AffectedUsers    []primitive.ObjectID `bson:"affected_users,omitempty" json:"-"` // Users whose progress needs rebuilding
+	// Submissions to evaluate again, and how many retry passes have begun
+	FailedSubmissions []primitive.ObjectID `bson:"failed_submissions,omitempty" json:"-"`
+	RetryPass         int                  `bson:"retry_pass,omitempty" json:"-"`
+	LeaseUntil        *time.Time           `bson:"lease_until,omitempty" json:"-"`
+	StartedBy         primitive.ObjectID   `bson:"started_by" json:"startedBy"`
+	CreatedAt         time.Time            `bson:"created_at" json:"createdAt"`
+	UpdatedAt         time.Time            `bson:"updated_at" json:"updatedAt"`
+	FinishedAt        *time.Time           `bson:"finished_at,omitempty" json:"finishedAt,omitempty"`
+}
+
+type RejudgeRequest struct {
+	Verdict string     `json:"verdict"` // Only rejudge submissions with this verdict
+	UserID  string     `json:"userId"`  // Only rejudge this user's submissions
+	Since   *time.Time `json:"since"`   // Only rejudge submissions created at or after this time
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Session is one signed-in device. Its refresh token rotates on every use;
+// presenting a token that has already been rotated out means it leaked, and
+// the whole session is revoked.
+type Session struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID         primitive.ObjectID `bson:"user_id" json:"-"`
+	TokenHash      string             `bson:"token_hash" json:"-"`                // SHA-256 of the current refresh token
+	PreviousHashes []string           `bson:"previous_hashes,omitempty" json:"-"` // Rotated out; seeing one again is reuse
+	UserAgent      string             `bson:"user_agent" json:"userAgent"`
+	IP             string             `bson:"ip" json:"ip"`
+	CreatedAt      time.Time          `bson:"created_at" json:"createdAt"`
+	LastUsedAt     time.Time          `bson:"last_used_at" json:"lastUsedAt"`
+	ExpiresAt      time.Time          `bson:"expires_at" json:"expiresAt"`
+	RevokedAt      *time.Time         `bson:"revoked_at,omitempty" json:"-"`
+	RevokedReason  string             `bson:"revoked_reason,omitempty" json:"-"` // e.g. "logout", "revoked", "reuse", "password_reset"
+	Current        bool               `bson:"-" json:"current"`                  // The session making the request
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
+}
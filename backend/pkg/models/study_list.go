@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// StudyList is a curated, ordered set of problems such as "Blind 75". Unlike
+// TopicSequence, any user can create as many as they like.
+type StudyList struct {
+	ID            primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
+	Name          string               `bson:"name" json:"name"`
+	Description   string               `bson:"description" json:"description"`
+	OwnerID       primitive.ObjectID   `bson:"owner_id" json:"ownerId"`
+	OwnerUsername string               `bson:"owner_username" json:"ownerUsername"`
+	Visibility    string               `bson:"visibility" json:"visibility"` // "public", "private"
+	ProblemIDs    []primitive.ObjectID `bson:"problem_ids" json:"problemIds"`
+	ClonedFrom    *primitive.ObjectID  `bson:"cloned_from,omitempty" json:"clonedFrom,omitempty"`
+	Followers     int                  `bson:"followers" json:"followers"`
+	CreatedAt     time.Time            `bson:"created_at" json:"createdAt"`
+	UpdatedAt     time.Time            `bson:"updated_at" json:"updatedAt"`
+}
+
+type ListFollow struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID    primitive.ObjectID `bson:"user_id" json:"userId"`
+	ListID    primitive.ObjectID `bson:"list_id" json:"listId"`
+	CreatedAt time.Time          `bson:"created_at" json:"createdAt"`
+}
+
+type ListProgress struct {
+	Total     int     `json:"total"`
+	Solved    int     `json:"solved"`
+	Attempted int     `json:"attempted"`
+	Percent   float64 `json:"percent"` // Solved / Total * 100
+}
+
+// StudyListDetail is a list with its problems resolved in order and, for a
+// signed-in caller, their progress through it.
+type StudyListDetail struct {
+	StudyList `bson:",inline"`
+	Problems  []ProblemListItem `json:"problems"`
+	Following bool              `json:"following"`
+	Progress  *ListProgress     `json:"progress,omitempty"`
+}
+
+type StudyListRequest struct {
+	Name        string   `json:"name" binding:"required,max=100"`
+	Description string   `json:"description" binding:"max=2000"`
+	Visibility  string   `json:"visibility" binding:"omitempty,oneof=public private"`
+	ProblemIDs  []string `json:"problemIds"` // IDs or slugs, in order
+}
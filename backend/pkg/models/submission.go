@@ -7,14 +7,15 @@ import (
 )
 
 type Submission struct {
-	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	UserID    primitive.ObjectID `bson:"user_id" json:"userId"`
-	ProblemID primitive.ObjectID `bson:"problem_id" json:"problemId"`
-	Code      string             `bson:"code" json:"code"`
-	Language  string             `bson:"language" json:"language"`
-	Verdict   string             `bson:"verdict" json:"verdict"` // Accepted, Wrong Answer, Runtime Error, Compilation Error
-	Feedback  string             `bson:"feedback" json:"feedback"`
-	CreatedAt time.Time          `bson:"created_at" json:"createdAt"`
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID     primitive.ObjectID `bson:"user_id" json:"userId"`
+	ProblemID  primitive.ObjectID `bson:"problem_id" json:"problemId"`
+	Code       string             `bson:"code" json:"code"`
+	Language   string             `bson:"language" json:"language"`
+	Verdict    string             `bson:"verdict" json:"verdict"` // Accepted, Wrong Answer, Runtime Error, Compilation Error
+	Feedback   string             `bson:"feedback" json:"feedback"`
+	CreatedAt  time.Time          `bson:"created_at" json:"createdAt"`
+	RejudgedAt *time.Time         `bson:"rejudged_at,omitempty" json:"rejudgedAt,omitempty"`
 }
 
 type SubmitRequest struct {
@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// TwoFactor holds a user's TOTP settings. Only Enabled and EnabledAt are
+// ever sent to clients.
+type TwoFactor struct {
+	Enabled       bool       `bson:"enabled" json:"enabled"`
+	Secret        string     `bson:"secret,omitempty" json:"-"`
+	PendingSecret string     `bson:"pending_secret,omitempty" json:"-"` // Enrolled but not yet confirmed with a code
+	RecoveryCodes []string   `bson:"recovery_codes,omitempty" json:"-"` // Hashes of the unused recovery codes
+	LastStep      int64      `bson:"last_step" json:"-"`                // Time step of the last accepted code, to stop replays
+	EnabledAt     *time.Time `bson:"enabled_at,omitempty" json:"enabledAt,omitempty"`
+}
+
+type TwoFactorEnrollResponse struct {
+	Secret     string `json:"secret"`
+	OtpauthURI string `json:"otpauthUri"`
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"` // Authenticator code, or a recovery code where accepted
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes"` // Shown once; only hashes are kept
+}
+
+// TwoFactorChallenge is what Login returns instead of an AuthResponse when
+// the account has two-factor authentication on.
+type TwoFactorChallenge struct {
+	TwoFactorRequired bool   `json:"twoFactorRequired"`
+	ChallengeToken    string `json:"challengeToken"`
+	ExpiresIn         int    `json:"expiresIn"` // Seconds left to enter the code
+}
+
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challengeToken" binding:"required"`
+	Code           string `json:"code" binding:"required"`
+}
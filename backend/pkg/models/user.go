@@ -7,19 +7,71 @@ import (
 )
 
 type User struct {
-	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	Username      string             `bson:"username" json:"username"`
-	PasswordHash  string             `bson:"password_hash" json:"-"`
-	ApiKey        string             `bson:"api_key,omitempty" json:"apiKey,omitempty"`
-	TrialUsage    int                `bson:"trial_usage" json:"trialUsage"` // Count of system-key usages
-	SolvedCount   int                `bson:"solved_count" json:"solvedCount"`
-	LastSolveDate *time.Time         `bson:"last_solve_date,omitempty" json:"lastSolveDate,omitempty"`
-	CreatedAt     time.Time          `bson:"created_at" json:"createdAt"`
+	ID              primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Username        string             `bson:"username" json:"username"`
+	UsernameHistory []UsernameChange   `bson:"username_history,omitempty" json:"usernameHistory,omitempty"` // Oldest first
+	PasswordHash    string             `bson:"password_hash" json:"-"`
+	Email           string             `bson:"email,omitempty" json:"email,omitempty"` // Optional, stored lowercase
+	EmailVerified   bool               `bson:"email_verified" json:"emailVerified"`
+	Identities      []Identity         `bson:"identities,omitempty" json:"identities,omitempty"` // Linked sign-in providers
+	TwoFactor       *TwoFactor         `bson:"two_factor,omitempty" json:"twoFactor,omitempty"`
+	ApiKey          string             `bson:"api_key,omitempty" json:"apiKey,omitempty"`
+	Role            string             `bson:"role,omitempty" json:"role,omitempty"`     // One of the Role constants; empty means RoleUser
+	Locale          string             `bson:"locale,omitempty" json:"locale,omitempty"` // Preferred locale; overrides Accept-Language
+	TrialUsage      int                `bson:"trial_usage" json:"trialUsage"`            // Count of system-key usages
+	SolvedCount     int                `bson:"solved_count" json:"solvedCount"`
+	LastSolveDate   *time.Time         `bson:"last_solve_date,omitempty" json:"lastSolveDate,omitempty"`
+	CreatedAt       time.Time          `bson:"created_at" json:"createdAt"`
+
+	// Public profile, shown at GET /api/users/:username unless hidden
+	DisplayName       string        `bson:"display_name,omitempty" json:"displayName,omitempty"`
+	Bio               string        `bson:"bio,omitempty" json:"bio,omitempty"`
+	AvatarURL         string        `bson:"avatar_url,omitempty" json:"avatarUrl,omitempty"`
+	Links             []ProfileLink `bson:"links,omitempty" json:"links,omitempty"`
+	ProfileVisibility string        `bson:"profile_visibility,omitempty" json:"profileVisibility,omitempty"` // "public" (the default) or "private"
+}
+
+type UsernameChange struct {
+	Username  string    `bson:"username" json:"username"` // The name given up
+	ChangedAt time.Time `bson:"changed_at" json:"changedAt"`
+}
+
+type ProfileLink struct {
+	Label string `bson:"label" json:"label" binding:"required,max=30"`
+	URL   string `bson:"url" json:"url" binding:"required,url,max=300"`
+}
+
+// Roles, from least to most trusted. Moderators look after discussions,
+// instructors curate problem content, admins can do anything.
+const (
+	RoleUser       = "user"
+	RoleModerator  = "moderator"
+	RoleInstructor = "instructor"
+	RoleAdmin      = "admin"
+)
+
+var Roles = []string{RoleUser, RoleModerator, RoleInstructor, RoleAdmin}
+
+// EffectiveRole is the user's role, with the unset default spelled out.
+func (u User) EffectiveRole() string {
+	if u.Role == "" {
+		return RoleUser
+	}
+	return u.Role
+}
+
+// Identity links an account at an external sign-in provider to a user.
+type Identity struct {
+	Provider string    `bson:"provider" json:"provider"`
+	Subject  string    `bson:"subject" json:"-"` // The provider's stable user ID
+	Username string    `bson:"username,omitempty" json:"username,omitempty"`
+	LinkedAt time.Time `bson:"linked_at" json:"linkedAt"`
 }
 
 type RegisterRequest struct {
 	Username string `json:"username" binding:"required,min=3,max=30"`
 	Password string `json:"password" binding:"required,min=6"`
+	Email    string `json:"email" binding:"omitempty,email"`
 	ApiKey   string `json:"apiKey"`
 }
 
@@ -29,6 +81,40 @@ type LoginRequest struct {
 }
 
 type AuthResponse struct {
-	Token string `json:"token"`
-	User  User   `json:"user"`
+	Token        string `json:"token"` // Short-lived access token
+	RefreshToken string `json:"refreshToken"`
+	ExpiresIn    int    `json:"expiresIn"` // Seconds until Token expires
+	User         User   `json:"user"`
+}
+
+type UpdateLocaleRequest struct {
+	Locale string `json:"locale"` // Empty clears the preference
+}
+
+type UpdateRoleRequest struct {
+	Role   string `json:"role" binding:"required"`
+	Reason string `json:"reason" binding:"max=500"` // Kept in the audit log
+}
+
+type ChangeUsernameRequest struct {
+	Username string `json:"username" binding:"required,min=3,max=30"`
+}
+
+type UpdateProfileRequest struct {
+	DisplayName string        `json:"displayName" binding:"max=50"`
+	Bio         string        `json:"bio" binding:"max=500"`
+	AvatarURL   string        `json:"avatarUrl" binding:"omitempty,url,max=300"`
+	Links       []ProfileLink `json:"links" binding:"max=5,dive"`
+	Visibility  string        `json:"visibility" binding:"omitempty,oneof=public private"`
+}
+
+// PublicProfile is what other people see of a user.
+type PublicProfile struct {
+	Username    string        `json:"username"`
+	DisplayName string        `json:"displayName,omitempty"`
+	Bio         string        `json:"bio,omitempty"`
+	AvatarURL   string        `json:"avatarUrl,omitempty"`
+	Links       []ProfileLink `json:"links,omitempty"`
+	SolvedCount int           `json:"solvedCount"`
+	CreatedAt   time.Time     `json:"createdAt"`
 }
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// UserToken is a single-use token handed to a user, such as an email
+// verification or password reset link, or the challenge between the password
+// and two-factor steps of a login. Only its hash is stored.
+type UserToken struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	UserID    primitive.ObjectID `bson:"user_id"`
+	Purpose   string             `bson:"purpose"` // "verify_email", "reset_password" or "login_2fa"
+	TokenHash string             `bson:"token_hash"`
+	Email     string             `bson:"email"` // Address the token was sent to
+	CreatedAt time.Time          `bson:"created_at"`
+	ExpiresAt time.Time          `bson:"expires_at"`
+	UsedAt    *time.Time         `bson:"used_at,omitempty"`
+	Attempts  int                `bson:"attempts,omitempty"` // Wrong codes entered against a login challenge
+}
+
+type UpdateEmailRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type TokenRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type ChangePasswordRequest struct {
+	OldPassword string `json:"oldPassword"` // Empty for accounts without a password
+	NewPassword string `json:"newPassword" binding:"required,min=6"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"newPassword" binding:"required,min=6"`
+}
@@ -0,0 +1,215 @@
+package services
+
+import (
+	"bytes"
+	"regexp"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// StatementRendererVersion is part of the key cached renderings are stored
+// under. Bump it whenever the output of RenderStatement changes so stale HTML
+// gets rebuilt.
+const StatementRendererVersion = 1
+
+// Problem statements are GitHub flavoured markdown with LaTeX math: $...$
+// inline and $$...$$ for display. Math is passed through escaped, wrapped in
+// \( \) or \[ \] delimiters for the client's KaTeX/MathJax auto-render.
+// Raw HTML in the source is never rendered.
+var statementMarkdown = goldmark.New(
+	goldmark.WithExtensions(extension.GFM, mathExtension{}),
+)
+
+var statementPolicy = func() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+	p.AllowAttrs("class").
+		Matching(regexp.MustCompile(`^(language-[\w+#-]+|math-inline|math-display)$`)).
+		OnElements("code", "span", "div")
+	return p
+}()
+
+// RenderStatement converts a markdown statement to sanitized HTML.
+func RenderStatement(source string) (string, error) {
+	var buf bytes.Buffer
+	if err := statementMarkdown.Convert([]byte(source), &buf); err != nil {
+		return "", err
+	}
+	return statementPolicy.Sanitize(buf.String()), nil
+}
+
+var (
+	kindMath      = ast.NewNodeKind("Math")
+	kindMathBlock = ast.NewNodeKind("MathBlock")
+)
+
+type mathNode struct {
+	ast.BaseInline
+	display  bool
+	segments []text.Segment
+}
+
+func (n *mathNode) Kind() ast.NodeKind { return kindMath }
+
+func (n *mathNode) Dump(source []byte, level int) { ast.DumpHelper(n, source, level, nil, nil) }
+
+type mathBlockNode struct {
+	ast.BaseBlock
+}
+
+func (n *mathBlockNode) Kind() ast.NodeKind { return kindMathBlock }
+
+func (n *mathBlockNode) IsRaw() bool { return true }
+
+func (n *mathBlockNode) Dump(source []byte, level int) { ast.DumpHelper(n, source, level, nil, nil) }
+
+type mathExtension struct{}
+
+func (mathExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(
+		// Ahead of fenced code (700) so a $$ line opens a math block
+		parser.WithBlockParsers(util.Prioritized(mathBlockParser{}, 690)),
+		parser.WithInlineParsers(util.Prioritized(mathInlineParser{}, 150)),
+	)
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(util.Prioritized(mathRenderer{}, 500)))
+}
+
+// mathBlockParser handles display math on lines of its own:
+//
+//	$$
+//	\sum_{i=1}^{n} a_i
+//	$$
+type mathBlockParser struct{}
+
+var mathFence = []byte("$$")
+
+func (mathBlockParser) Trigger() []byte { return []byte{'$'} }
+
+func (mathBlockParser) Open(parent ast.Node, reader text.Reader, pc parser.Context) (ast.Node, parser.State) {
+	line, segment := reader.PeekLine()
+	pos := pc.BlockOffset()
+	if pos < 0 || !bytes.Equal(util.TrimRightSpace(line[pos:]), mathFence) {
+		return nil, parser.NoChildren
+	}
+	reader.Advance(segment.Len() - 1)
+	return &mathBlockNode{}, parser.NoChildren
+}
+
+func (mathBlockParser) Continue(node ast.Node, reader text.Reader, pc parser.Context) parser.State {
+	line, segment := reader.PeekLine()
+	if bytes.Equal(bytes.TrimSpace(line), mathFence) {
+		reader.Advance(segment.Len() - 1)
+		return parser.Close
+	}
+	node.Lines().Append(segment)
+	reader.Advance(segment.Len() - 1)
+	return parser.Continue | parser.NoChildren
+}
+
+func (mathBlockParser) Close(node ast.Node, reader text.Reader, pc parser.Context) {}
+
+func (mathBlockParser) CanInterruptParagraph() bool { return true }
+
+func (mathBlockParser) CanAcceptIndentedLine() bool { return false }
+
+// mathInlineParser handles $...$ and $$...$$ within a paragraph. Like pandoc,
+// an inline opener must be followed by a non-space and a closer preceded by
+// one and not followed by a digit, so prices such as "$5 or $10" stay text.
+type mathInlineParser struct{}
+
+func (mathInlineParser) Trigger() []byte { return []byte{'$'} }
+
+func (mathInlineParser) Parse(parent ast.Node, block text.Reader, pc parser.Context) ast.Node {
+	startLine, startPos := block.Position()
+	line, _ := block.PeekLine()
+
+	opener := 1
+	if len(line) > 1 && line[1] == '$' {
+		opener = 2
+	}
+	if len(line) <= opener || (opener == 1 && util.IsSpace(line[1])) {
+		return nil
+	}
+	block.Advance(opener)
+
+	node := &mathNode{display: opener == 2}
+	for {
+		line, segment := block.PeekLine()
+		if line == nil {
+			block.SetPosition(startLine, startPos)
+			return nil
+		}
+		for i := 0; i < len(line); i++ {
+			switch {
+			case line[i] == '\\':
+				i++ // \$ is a literal dollar inside math
+			case line[i] != '$':
+			case opener == 2:
+				if i+1 < len(line) && line[i+1] == '$' {
+					node.segments = append(node.segments, segment.WithStop(segment.Start+i))
+					block.Advance(i + 2)
+					return node
+				}
+			case i > 0 && !util.IsSpace(line[i-1]) && !(i+1 < len(line) && util.IsNumeric(line[i+1])):
+				node.segments = append(node.segments, segment.WithStop(segment.Start+i))
+				block.Advance(i + 1)
+				return node
+			}
+		}
+		node.segments = append(node.segments, segment)
+		block.AdvanceLine()
+	}
+}
+
+type mathRenderer struct{}
+
+func (mathRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(kindMath, renderMath)
+	reg.Register(kindMathBlock, renderMathBlock)
+}
+
+func renderMath(w util.BufWriter, source []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	node := n.(*mathNode)
+	var tex []byte
+	for _, segment := range node.segments {
+		tex = append(tex, segment.Value(source)...)
+	}
+	tex = util.EscapeHTML(bytes.TrimSpace(tex))
+
+	if node.display {
+		_, _ = w.WriteString(`<span class="math-display">\[`)
+		_, _ = w.Write(tex)
+		_, _ = w.WriteString(`\]</span>`)
+	} else {
+		_, _ = w.WriteString(`<span class="math-inline">\(`)
+		_, _ = w.Write(tex)
+		_, _ = w.WriteString(`\)</span>`)
+	}
+	return ast.WalkSkipChildren, nil
+}
+
+func renderMathBlock(w util.BufWriter, source []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	var tex []byte
+	lines := n.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		segment := lines.At(i)
+		tex = append(tex, segment.Value(source)...)
+	}
+
+	_, _ = w.WriteString(`<div class="math-display">\[`)
+	_, _ = w.Write(util.EscapeHTML(bytes.TrimSpace(tex)))
+	_, _ = w.WriteString("\\]</div>\n")
+	return ast.WalkSkipChildren, nil
+}
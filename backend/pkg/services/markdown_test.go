@@ -0,0 +1,67 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func render(t *testing.T, source string) string {
+	t.Helper()
+	html, err := RenderStatement(source)
+	if err != nil {
+		t.Fatalf("RenderStatement: %v", err)
+	}
+	return html
+}
+
+func TestRenderStatementMarkdown(t *testing.T) {
+	html := render(t, "Given an array `nums`, return **two** indices.\n\n- one\n- two\n")
+
+	for _, want := range []string{"<code>nums</code>", "<strong>two</strong>", "<li>one</li>"} {
+		if !strings.Contains(html, want) {
+			t.Errorf("missing %q in %s", want, html)
+		}
+	}
+}
+
+func TestRenderStatementMath(t *testing.T) {
+	html := render(t, "Find $a_i < b_i$ for $1 \\le i \\le n$.\n\n$$\n\\sum_{i=1}^{n} a_i\n$$\n\nInline $$x^2$$ too.\n")
+
+	for _, want := range []string{
+		`<span class="math-inline">\(a_i &lt; b_i\)</span>`,
+		`<span class="math-inline">\(1 \le i \le n\)</span>`,
+		`<div class="math-display">\[\sum_{i=1}^{n} a_i\]</div>`,
+		`<span class="math-display">\[x^2\]</span>`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("missing %q in %s", want, html)
+		}
+	}
+}
+
+func TestRenderStatementLeavesPricesAlone(t *testing.T) {
+	for _, source := range []string{"It costs $5 or $10.\n", "`$x$` is code.\n", "Pay $ 5 now.\n"} {
+		if html := render(t, source); strings.Contains(html, "math-inline") {
+			t.Errorf("%q was treated as math: %s", source, html)
+		}
+	}
+}
+
+func TestRenderStatementFencedExamples(t *testing.T) {
+	html := render(t, "```example\nInput: nums = [2,7,11,15], target = 9\nOutput: [0,1]\n```\n")
+
+	if !strings.Contains(html, `<pre><code class="language-example">Input: nums = [2,7,11,15], target = 9`) {
+		t.Errorf("fenced example not rendered: %s", html)
+	}
+}
+
+func TestRenderStatementSanitizes(t *testing.T) {
+	html := render(t, "<script>alert(1)</script>\n\n[click](javascript:alert(1))\n\n<img src=x onerror=alert(1)>\n\n$<img src=x onerror=alert(1)>$\n")
+
+	// Markup inside math is escaped text, so only real tags are a problem
+	for _, bad := range []string{"<script", "javascript:", "<img"} {
+		if strings.Contains(html, bad) {
+			t.Errorf("unsafe %q survived in %s", bad, html)
+		}
+	}
+}
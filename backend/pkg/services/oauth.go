@@ -0,0 +1,203 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"woohoodsa/pkg/config"
+)
+
+var oauthClient = &http.Client{Timeout: 10 * time.Second}
+
+// OAuthIdentity is who the provider says signed in.
+type OAuthIdentity struct {
+	Subject       string // Stable ID at the provider
+	Username      string
+	Email         string
+	EmailVerified bool
+}
+
+// NewPKCE returns a code verifier and its S256 challenge (RFC 7636).
+func NewPKCE() (verifier, challenge string, err error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	verifier = base64.RawURLEncoding.EncodeToString(buf)
+	return verifier, PKCEChallenge(verifier), nil
+}
+
+func PKCEChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// AuthCodeURL is where to send the browser to start signing in.
+func AuthCodeURL(provider config.OAuthProvider, redirectURI, state, challenge string) string {
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {provider.ClientID},
+		"redirect_uri":          {redirectURI},
+		"scope":                 {strings.Join(provider.Scopes, " ")},
+		"state":                 {state},
+		"code_challenge":        {challenge},
+		"code_challenge_method": {"S256"},
+	}
+	separator := "?"
+	if strings.Contains(provider.AuthURL, "?") {
+		separator = "&"
+	}
+	return provider.AuthURL + separator + query.Encode()
+}
+
+// ExchangeCode redeems an authorization code for an access token.
+func ExchangeCode(ctx context.Context, provider config.OAuthProvider, redirectURI, code, verifier string) (string, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {redirectURI},
+		"client_id":     {provider.ClientID},
+		"client_secret": {provider.ClientSecret},
+		"code_verifier": {verifier},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, provider.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json") // GitHub answers form-encoded otherwise
+
+	var token struct {
+		AccessToken      string `json:"access_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := doOAuthJSON(req, &token); err != nil && token.Error == "" {
+		return "", err
+	}
+	if token.Error != "" {
+		return "", fmt.Errorf("token exchange failed: %s %s", token.Error, token.ErrorDescription)
+	}
+	if token.AccessToken == "" {
+		return "", errors.New("token exchange returned no access token")
+	}
+	return token.AccessToken, nil
+}
+
+// FetchIdentity looks up the signed-in user with an access token.
+func FetchIdentity(ctx context.Context, provider config.OAuthProvider, accessToken string) (*OAuthIdentity, error) {
+	switch provider.Kind {
+	case "github":
+		return fetchGitHubIdentity(ctx, provider, accessToken)
+	case "oidc":
+		return fetchOIDCIdentity(ctx, provider, accessToken)
+	}
+	return nil, fmt.Errorf("unknown provider kind %q", provider.Kind)
+}
+
+func fetchGitHubIdentity(ctx context.Context, provider config.OAuthProvider, accessToken string) (*OAuthIdentity, error) {
+	var user struct {
+		ID    int64  `json:"id"`
+		Login string `json:"login"`
+	}
+	if err := getOAuthJSON(ctx, provider.APIURL+"/user", accessToken, &user); err != nil {
+		return nil, err
+	}
+	if user.ID == 0 {
+		return nil, errors.New("github returned no user id")
+	}
+	identity := &OAuthIdentity{Subject: strconv.FormatInt(user.ID, 10), Username: user.Login}
+
+	// The profile email is optional and unverified; the emails endpoint says
+	// which address is primary and verified
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	if err := getOAuthJSON(ctx, provider.APIURL+"/user/emails", accessToken, &emails); err == nil {
+		for _, e := range emails {
+			if e.Primary && e.Verified {
+				identity.Email = e.Email
+				identity.EmailVerified = true
+			}
+		}
+	}
+	return identity, nil
+}
+
+func fetchOIDCIdentity(ctx context.Context, provider config.OAuthProvider, accessToken string) (*OAuthIdentity, error) {
+	var info struct {
+		Subject           string `json:"sub"`
+		Email             string `json:"email"`
+		EmailVerified     any    `json:"email_verified"` // Some providers send "true"
+		PreferredUsername string `json:"preferred_username"`
+		Name              string `json:"name"`
+	}
+	if err := getOAuthJSON(ctx, provider.UserInfoURL, accessToken, &info); err != nil {
+		return nil, err
+	}
+	if info.Subject == "" {
+		return nil, errors.New("userinfo returned no subject")
+	}
+
+	identity := &OAuthIdentity{
+		Subject:  info.Subject,
+		Username: info.PreferredUsername,
+		Email:    info.Email,
+	}
+	switch v := info.EmailVerified.(type) {
+	case bool:
+		identity.EmailVerified = v
+	case string:
+		identity.EmailVerified = v == "true"
+	}
+	if identity.Username == "" {
+		identity.Username = strings.Split(info.Email, "@")[0]
+	}
+	if identity.Username == "" {
+		identity.Username = info.Name
+	}
+	return identity, nil
+}
+
+func getOAuthJSON(ctx context.Context, endpoint, accessToken string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/json")
+	return doOAuthJSON(req, out)
+}
+
+// doOAuthJSON decodes the response body into out. Non-2xx statuses are
+// errors, but the body is still decoded so OAuth error fields are available.
+func doOAuthJSON(req *http.Request, out any) error {
+	resp, err := oauthClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	decodeErr := json.Unmarshal(body, out)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s %s: %s", req.Method, req.URL.Path, resp.Status)
+	}
+	return decodeErr
+}
@@ -0,0 +1,170 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"woohoodsa/pkg/config"
+)
+
+// stubProvider is a minimal identity provider. It issues a token only for
+// the expected code, redirect URI and a verifier matching the PKCE challenge
+// the authorize step was sent.
+type stubProvider struct {
+	*httptest.Server
+	challenge string
+}
+
+const stubRedirect = "http://localhost:8080/api/auth/oauth/stub/callback"
+
+func newStubProvider(t *testing.T) *stubProvider {
+	t.Helper()
+	stub := &stubProvider{}
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/authorize", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("code_challenge_method") != "S256" || q.Get("client_id") != "client" {
+			http.Error(w, "bad authorize request", http.StatusBadRequest)
+			return
+		}
+		stub.challenge = q.Get("code_challenge")
+		target, _ := url.Parse(q.Get("redirect_uri"))
+		target.RawQuery = url.Values{"code": {"the-code"}, "state": {q.Get("state")}}.Encode()
+		http.Redirect(w, r, target.String(), http.StatusFound)
+	})
+
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Form.Get("code") != "the-code" || r.Form.Get("redirect_uri") != stubRedirect:
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+		case PKCEChallenge(r.Form.Get("code_verifier")) != stub.challenge:
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant", "error_description": "PKCE mismatch"})
+		default:
+			json.NewEncoder(w).Encode(map[string]string{"access_token": "the-token", "token_type": "bearer"})
+		}
+	})
+
+	authorized := func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer the-token" {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			next(w, r)
+		}
+	}
+	mux.HandleFunc("/userinfo", authorized(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"sub": "oidc-42", "email": "ada@example.com", "email_verified": true, "preferred_username": "ada",
+		})
+	}))
+	mux.HandleFunc("/user", authorized(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"id": 42, "login": "octo"})
+	}))
+	mux.HandleFunc("/user/emails", authorized(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode([]map[string]any{
+			{"email": "old@example.com", "primary": false, "verified": true},
+			{"email": "octo@example.com", "primary": true, "verified": true},
+		})
+	}))
+
+	stub.Server = httptest.NewServer(mux)
+	t.Cleanup(stub.Close)
+	return stub
+}
+
+func (s *stubProvider) config(kind string) config.OAuthProvider {
+	return config.OAuthProvider{
+		Kind:         kind,
+		ClientID:     "client",
+		ClientSecret: "secret",
+		AuthURL:      s.URL + "/authorize",
+		TokenURL:     s.URL + "/token",
+		UserInfoURL:  s.URL + "/userinfo",
+		APIURL:       s.URL,
+		Scopes:       []string{"openid", "email"},
+	}
+}
+
+// authorize follows the authorize redirect like a browser would and returns
+// the code and state handed back to the redirect URI.
+func (s *stubProvider) authorize(t *testing.T, authURL string) (string, string) {
+	t.Helper()
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(authURL)
+	if err != nil {
+		t.Fatalf("authorize: %v", err)
+	}
+	resp.Body.Close()
+	location, err := resp.Location()
+	if err != nil {
+		t.Fatalf("authorize status %s, no redirect", resp.Status)
+	}
+	return location.Query().Get("code"), location.Query().Get("state")
+}
+
+func TestOAuthOIDCFlowWithPKCE(t *testing.T) {
+	stub := newStubProvider(t)
+	provider := stub.config("oidc")
+	ctx := context.Background()
+
+	verifier, challenge, err := NewPKCE()
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, state := stub.authorize(t, AuthCodeURL(provider, stubRedirect, "state-1", challenge))
+	if state != "state-1" {
+		t.Errorf("state = %q, want state-1", state)
+	}
+
+	token, err := ExchangeCode(ctx, provider, stubRedirect, code, verifier)
+	if err != nil {
+		t.Fatalf("ExchangeCode: %v", err)
+	}
+	identity, err := FetchIdentity(ctx, provider, token)
+	if err != nil {
+		t.Fatalf("FetchIdentity: %v", err)
+	}
+
+	want := OAuthIdentity{Subject: "oidc-42", Username: "ada", Email: "ada@example.com", EmailVerified: true}
+	if *identity != want {
+		t.Errorf("identity = %+v, want %+v", *identity, want)
+	}
+}
+
+func TestOAuthRejectsWrongVerifier(t *testing.T) {
+	stub := newStubProvider(t)
+	provider := stub.config("oidc")
+
+	_, challenge, _ := NewPKCE()
+	code, _ := stub.authorize(t, AuthCodeURL(provider, stubRedirect, "s", challenge))
+
+	otherVerifier, _, _ := NewPKCE()
+	if _, err := ExchangeCode(context.Background(), provider, stubRedirect, code, otherVerifier); err == nil {
+		t.Fatal("exchange with a mismatched verifier succeeded")
+	}
+}
+
+func TestOAuthGitHubUsesPrimaryVerifiedEmail(t *testing.T) {
+	stub := newStubProvider(t)
+	provider := stub.config("github")
+
+	identity, err := FetchIdentity(context.Background(), provider, "the-token")
+	if err != nil {
+		t.Fatalf("FetchIdentity: %v", err)
+	}
+
+	want := OAuthIdentity{Subject: "42", Username: "octo", Email: "octo@example.com", EmailVerified: true}
+	if *identity != want {
+		t.Errorf("identity = %+v, want %+v", *identity, want)
+	}
+}
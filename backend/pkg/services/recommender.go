@@ -0,0 +1,190 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+
+	"woohoodsa/pkg/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Scoring weights. Each signal that applies to a problem adds its weight and
+// a human readable reason; problems are ranked by the total.
+const (
+	weightRetry          = 3.0 // Attempted but not yet solved
+	weightWeakTopic      = 2.5 // Scaled by how weak the topic is
+	weightNextInSequence = 2.0 // First unsolved problem in a topic already started
+	weightLevel          = 1.5 // Difficulty matches where the user is
+	weightStart          = 1.0 // First problem of a topic for a brand new user
+	penaltyTooHard       = 1.0 // Two or more steps above the user's level
+
+	// A topic counts as weak once it has this many failed submissions and
+	// failures make up at least weakThreshold of its weakness ratio
+	weakMinFailures = 2
+	weakThreshold   = 0.5
+
+	// Solves needed at a difficulty before the next one up is recommended
+	levelUpSolves = 3
+)
+
+var difficultyRank = map[string]int{
+	"Easy":   0,
+	"Medium": 1,
+	"Hard":   2,
+}
+
+var difficultyNames = []string{"Easy", "Medium", "Hard"}
+
+type topicStats struct {
+	solved   int
+	failures int
+}
+
+// Recommend ranks the problems the user hasn't solved yet. It is a pure
+// function of its inputs so that results are reproducible: ties are broken by
+// topic sequence, then title, then ID.
+func Recommend(problems []models.ProblemListItem, progress []models.Progress, limit int) []models.Recommendation {
+	byProblem := map[primitive.ObjectID]models.Progress{}
+	for _, p := range progress {
+		byProblem[p.ProblemID] = p
+	}
+
+	// Per-topic history and the user's overall difficulty level
+	topics := map[string]*topicStats{}
+	solvedAtRank := make([]int, len(difficultyNames))
+	for _, problem := range problems {
+		stats, ok := topics[problem.Topic]
+		if !ok {
+			stats = &topicStats{}
+			topics[problem.Topic] = stats
+		}
+		p, ok := byProblem[problem.ID]
+		if !ok {
+			continue
+		}
+		stats.failures += p.Attempts - p.SuccessfulSubmissions
+		if p.Status == "solved" {
+			stats.solved++
+			if rank, ok := difficultyRank[problem.Difficulty]; ok {
+				solvedAtRank[rank]++
+			}
+		}
+	}
+	target := targetRank(solvedAtRank)
+
+	ordered := append([]models.ProblemListItem{}, problems...)
+	sort.SliceStable(ordered, func(i, j int) bool { return lessProblem(ordered[i], ordered[j]) })
+
+	// The first unsolved problem of each topic, in topic_sequence order
+	firstUnsolved := map[string]primitive.ObjectID{}
+	firstOverall := map[string]primitive.ObjectID{}
+	for _, problem := range ordered {
+		if _, ok := firstOverall[problem.Topic]; !ok {
+			firstOverall[problem.Topic] = problem.ID
+		}
+		if byProblem[problem.ID].Status == "solved" {
+			continue
+		}
+		if _, ok := firstUnsolved[problem.Topic]; !ok {
+			firstUnsolved[problem.Topic] = problem.ID
+		}
+	}
+
+	var recommendations []models.Recommendation
+	for _, problem := range ordered {
+		p, attempted := byProblem[problem.ID]
+		if p.Status == "solved" {
+			continue
+		}
+
+		rec := models.Recommendation{Problem: problem}
+		add := func(score float64, reason string) {
+			rec.Score += score
+			if reason != "" {
+				rec.Reasons = append(rec.Reasons, reason)
+			}
+		}
+
+		if attempted && p.Attempts > 0 {
+			add(weightRetry, fmt.Sprintf("You've attempted this %d time(s)", p.Attempts))
+		}
+
+		stats := topics[problem.Topic]
+		if w := weakness(stats); w >= weakThreshold && stats.failures >= weakMinFailures {
+			add(weightWeakTopic*w, "Weak in "+problem.Topic)
+		}
+
+		if stats.solved > 0 && firstUnsolved[problem.Topic] == problem.ID {
+			add(weightNextInSequence, "Next in "+problem.Topic+" sequence")
+		}
+
+		if len(progress) == 0 && firstOverall[problem.Topic] == problem.ID {
+			add(weightStart, "Good place to start "+problem.Topic)
+		}
+
+		if rank, ok := difficultyRank[problem.Difficulty]; ok {
+			switch {
+			case rank == target:
+				add(weightLevel, "Matches your level ("+problem.Difficulty+")")
+			case rank >= target+2:
+				add(-penaltyTooHard, "")
+			}
+		}
+
+		if rec.Score > 0 && len(rec.Reasons) > 0 {
+			rec.Problem.Status = p.EffectiveStatus()
+			rec.Problem.Attempts = p.Attempts
+			recommendations = append(recommendations, rec)
+		}
+	}
+
+	sort.SliceStable(recommendations, func(i, j int) bool {
+		if recommendations[i].Score != recommendations[j].Score {
+			return recommendations[i].Score > recommendations[j].Score
+		}
+		return lessProblem(recommendations[i].Problem, recommendations[j].Problem)
+	})
+
+	if limit > 0 && len(recommendations) > limit {
+		recommendations = recommendations[:limit]
+	}
+	if recommendations == nil {
+		recommendations = []models.Recommendation{}
+	}
+	return recommendations
+}
+
+// targetRank is the difficulty to recommend: the hardest one the user has
+// solved anything at, moving up a step once they have levelUpSolves there.
+func targetRank(solvedAtRank []int) int {
+	target := 0
+	for rank, solved := range solvedAtRank {
+		if solved > 0 {
+			target = rank
+		}
+	}
+	if solvedAtRank[target] >= levelUpSolves && target < len(solvedAtRank)-1 {
+		target++
+	}
+	return target
+}
+
+// weakness is the share of a topic's outcomes that were failures, counting
+// each solve twice so one success outweighs a single wrong answer.
+func weakness(stats *topicStats) float64 {
+	if stats == nil || stats.failures == 0 {
+		return 0
+	}
+	return float64(stats.failures) / float64(stats.failures+2*stats.solved)
+}
+
+func lessProblem(a, b models.ProblemListItem) bool {
+	if a.TopicSequence != b.TopicSequence {
+		return a.TopicSequence < b.TopicSequence
+	}
+	if a.Title != b.Title {
+		return a.Title < b.Title
+	}
+	return a.ID.Hex() < b.ID.Hex()
+}
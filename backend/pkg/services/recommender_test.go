@@ -0,0 +1,165 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"woohoodsa/pkg/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func fixtureID(n byte) primitive.ObjectID {
+	var id primitive.ObjectID
+	id[11] = n
+	return id
+}
+
+// fixtureProblems is a small catalog: two topics, each ordered by
+// topic_sequence from Easy to Hard.
+func fixtureProblems() []models.ProblemListItem {
+	return []models.ProblemListItem{
+		{ID: fixtureID(1), Title: "Two Sum", Topic: "Arrays", Difficulty: "Easy", TopicSequence: 1},
+		{ID: fixtureID(2), Title: "Best Time to Buy", Topic: "Arrays", Difficulty: "Easy", TopicSequence: 2},
+		{ID: fixtureID(3), Title: "Product Except Self", Topic: "Arrays", Difficulty: "Medium", TopicSequence: 3},
+		{ID: fixtureID(4), Title: "Trapping Rain Water", Topic: "Arrays", Difficulty: "Hard", TopicSequence: 4},
+		{ID: fixtureID(5), Title: "Flood Fill", Topic: "Graphs", Difficulty: "Easy", TopicSequence: 1},
+		{ID: fixtureID(6), Title: "Course Schedule", Topic: "Graphs", Difficulty: "Medium", TopicSequence: 2},
+		{ID: fixtureID(7), Title: "Word Ladder", Topic: "Graphs", Difficulty: "Hard", TopicSequence: 3},
+	}
+}
+
+func solved(id byte, attempts int) models.Progress {
+	return models.Progress{ProblemID: fixtureID(id), Status: "solved", Attempts: attempts, SuccessfulSubmissions: 1}
+}
+
+func attempted(id byte, attempts int) models.Progress {
+	return models.Progress{ProblemID: fixtureID(id), Status: "attempted", Attempts: attempts}
+}
+
+func ids(recs []models.Recommendation) []primitive.ObjectID {
+	out := make([]primitive.ObjectID, len(recs))
+	for i, r := range recs {
+		out[i] = r.Problem.ID
+	}
+	return out
+}
+
+func hasReason(rec models.Recommendation, reason string) bool {
+	for _, r := range rec.Reasons {
+		if r == reason {
+			return true
+		}
+	}
+	return false
+}
+
+func TestRecommendNewUserStartsWithFirstEasyOfEachTopic(t *testing.T) {
+	recs := Recommend(fixtureProblems(), nil, 2)
+
+	// Equal scores and sequence, so title breaks the tie
+	want := []primitive.ObjectID{fixtureID(5), fixtureID(1)}
+	if got := ids(recs); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if !hasReason(recs[0], "Good place to start Graphs") {
+		t.Errorf("reasons = %v, want a starting point reason", recs[0].Reasons)
+	}
+}
+
+func TestRecommendExcludesSolvedProblems(t *testing.T) {
+	progress := []models.Progress{solved(1, 1), solved(5, 1)}
+
+	for _, rec := range Recommend(fixtureProblems(), progress, 0) {
+		if rec.Problem.ID == fixtureID(1) || rec.Problem.ID == fixtureID(5) {
+			t.Errorf("solved problem %s was recommended", rec.Problem.Title)
+		}
+	}
+}
+
+func TestRecommendRetryAndWeakTopicRankFirst(t *testing.T) {
+	progress := []models.Progress{
+		solved(1, 1),
+		solved(2, 1),
+		attempted(6, 3), // Three failures in Graphs and no solves there
+	}
+
+	recs := Recommend(fixtureProblems(), progress, 3)
+
+	if recs[0].Problem.ID != fixtureID(6) {
+		t.Fatalf("top recommendation = %s, want Course Schedule", recs[0].Problem.Title)
+	}
+	if !hasReason(recs[0], "You've attempted this 3 time(s)") || !hasReason(recs[0], "Weak in Graphs") {
+		t.Errorf("reasons = %v, want retry and weak topic", recs[0].Reasons)
+	}
+	if recs[0].Problem.Status != "attempted" || recs[0].Problem.Attempts != 3 {
+		t.Errorf("problem status = %q/%d, want attempted/3", recs[0].Problem.Status, recs[0].Problem.Attempts)
+	}
+}
+
+func TestRecommendNextInSequence(t *testing.T) {
+	progress := []models.Progress{solved(1, 1)}
+
+	recs := Recommend(fixtureProblems(), progress, 0)
+
+	var next *models.Recommendation
+	for i := range recs {
+		if hasReason(recs[i], "Next in Arrays sequence") {
+			if next != nil {
+				t.Fatalf("more than one problem marked next in Arrays")
+			}
+			next = &recs[i]
+		}
+	}
+	if next == nil || next.Problem.ID != fixtureID(2) {
+		t.Fatalf("next in sequence = %v, want Best Time to Buy", next)
+	}
+	if recs[0].Problem.ID != fixtureID(2) {
+		t.Errorf("top recommendation = %s, want Best Time to Buy", recs[0].Problem.Title)
+	}
+}
+
+func TestRecommendLevelsUpAfterEnoughSolves(t *testing.T) {
+	problems := append(fixtureProblems(),
+		models.ProblemListItem{ID: fixtureID(8), Title: "Contains Duplicate", Topic: "Arrays", Difficulty: "Easy", TopicSequence: 5},
+	)
+	progress := []models.Progress{solved(1, 1), solved(2, 1), solved(8, 1)}
+
+	recs := Recommend(problems, progress, 0)
+
+	for _, rec := range recs {
+		if rec.Problem.Difficulty == "Medium" && !hasReason(rec, "Matches your level (Medium)") {
+			t.Errorf("%s reasons = %v, want Medium to match level", rec.Problem.Title, rec.Reasons)
+		}
+		if rec.Problem.Difficulty == "Easy" && hasReason(rec, "Matches your level (Easy)") {
+			t.Errorf("%s still matched Easy after levelling up", rec.Problem.Title)
+		}
+	}
+}
+
+func TestRecommendIsDeterministic(t *testing.T) {
+	problems := fixtureProblems()
+	progress := []models.Progress{solved(1, 2), attempted(3, 1), attempted(7, 4)}
+
+	first := Recommend(problems, progress, 0)
+
+	// Shuffle the inputs; the output must not depend on their order
+	reversed := make([]models.ProblemListItem, len(problems))
+	for i, p := range problems {
+		reversed[len(problems)-1-i] = p
+	}
+	second := Recommend(reversed, []models.Progress{progress[2], progress[0], progress[1]}, 0)
+
+	if !reflect.DeepEqual(first, second) {
+		t.Fatalf("recommendations differ between runs:\n%v\n%v", first, second)
+	}
+}
+
+func TestRecommendRespectsLimit(t *testing.T) {
+	if got := len(Recommend(fixtureProblems(), nil, 1)); got != 1 {
+		t.Errorf("len = %d, want 1", got)
+	}
+	if got := Recommend(nil, nil, 5); got == nil || len(got) != 0 {
+		t.Errorf("empty catalog = %v, want empty slice", got)
+	}
+}
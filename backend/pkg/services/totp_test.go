@@ -0,0 +1,63 @@
+package services
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+// RFC 6238 appendix B vectors for SHA-1, truncated to six digits.
+func TestValidateTOTPVectors(t *testing.T) {
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+	cases := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, tc := range cases {
+		step, ok := ValidateTOTP(secret, tc.code, time.Unix(tc.unix, 0))
+		if !ok {
+			t.Errorf("code %s at %d rejected", tc.code, tc.unix)
+			continue
+		}
+		if step != tc.unix/totpPeriod {
+			t.Errorf("code %s matched step %d, want %d", tc.code, step, tc.unix/totpPeriod)
+		}
+	}
+}
+
+func TestValidateTOTPWindow(t *testing.T) {
+	secret, err := NewTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, _ := totpEncoding.DecodeString(secret)
+	now := time.Unix(1_700_000_000, 0)
+	step := now.Unix() / totpPeriod
+
+	if _, ok := ValidateTOTP(secret, hotp(key, step-1), now); !ok {
+		t.Error("code from the previous step rejected")
+	}
+	if _, ok := ValidateTOTP(strings.ToLower(secret), hotp(key, step), now); !ok {
+		t.Error("lowercase secret rejected")
+	}
+	if _, ok := ValidateTOTP(secret, hotp(key, step-3), now); ok {
+		t.Error("code from three steps ago accepted")
+	}
+	if _, ok := ValidateTOTP(secret, "12345", now); ok {
+		t.Error("short code accepted")
+	}
+}
+
+func TestTOTPURI(t *testing.T) {
+	uri := TOTPURI("Woohoo DSA", "ada", "ABC")
+	want := "otpauth://totp/Woohoo%20DSA:ada?algorithm=SHA1&digits=6&issuer=Woohoo+DSA&period=30&secret=ABC"
+	if uri != want {
+		t.Errorf("uri = %s\nwant  %s", uri, want)
+	}
+}
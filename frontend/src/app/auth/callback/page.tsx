@@ -0,0 +1,67 @@
+"use client";
+import Link from "next/link";
+import { useEffect, useState } from "react";
+import { useRouter } from "next/navigation";
+import { authAPI, saveSession } from "@/lib/api";
+
+// Landing page for OAuth sign-ins. The API puts the outcome in the URL
+// fragment so tokens never show up in server logs.
+export default function OAuthCallbackPage() {
+    const router = useRouter();
+    const [error, setError] = useState("");
+
+    useEffect(() => {
+        const params = new URLSearchParams(window.location.hash.slice(1));
+        window.history.replaceState(null, "", window.location.pathname);
+        const redirect = params.get("redirect") || "/dashboard";
+
+        if (params.get("error")) {
+            setError(params.get("error") as string);
+            return;
+        }
+        if (params.get("challenge")) {
+            sessionStorage.setItem("twoFactorChallenge", params.get("challenge") as string);
+            sessionStorage.setItem("twoFactorRedirect", redirect);
+            router.replace("/auth/login");
+            return;
+        }
+        if (params.get("linked")) {
+            router.replace(redirect);
+            return;
+        }
+
+        const token = params.get("token");
+        const refreshToken = params.get("refreshToken");
+        if (!token || !refreshToken) {
+            setError("Sign-in failed. Please try again.");
+            return;
+        }
+        saveSession({ token, refreshToken });
+        authAPI
+            .getProfile()
+            .then((res) => {
+                localStorage.setItem("user", JSON.stringify(res.data));
+                router.replace(redirect);
+            })
+            .catch(() => setError("Sign-in failed. Please try again."));
+    }, [router]);
+
+    return (
+        <div className="min-h-screen bg-[var(--bg-primary)] flex items-center justify-center px-4 py-8">
+            <div className="glass-card p-6 sm:p-8 w-full max-w-sm text-center">
+                {error ? (
+                    <>
+                        <div className="bg-red-500/10 border border-red-500/30 text-red-400 px-4 py-3 rounded-lg text-sm mb-6">
+                            {error}
+                        </div>
+                        <Link href="/auth/login" className="text-[var(--accent-cyan)] hover:underline text-sm">
+                            Back to login
+                        </Link>
+                    </>
+                ) : (
+                    <p className="text-[var(--text-secondary)] text-sm">Signing you in...</p>
+                )}
+            </div>
+        </div>
+    );
+}
@@ -1,8 +1,8 @@
 "use client";
 import Link from "next/link";
-import { useState } from "react";
+import { useEffect, useState } from "react";
 import { useRouter } from "next/navigation";
-import { authAPI } from "@/lib/api";
+import { authAPI, saveSession, oauthStartURL } from "@/lib/api";
 
 export default function LoginPage() {
     const router = useRouter();
@@ -12,6 +12,24 @@ export default function LoginPage() {
     });
     const [error, setError] = useState("");
     const [loading, setLoading] = useState(false);
+    const [providers, setProviders] = useState<string[]>([]);
+    // Set once the password step passes on an account with 2FA
+    const [challenge, setChallenge] = useState("");
+    const [code, setCode] = useState("");
+    const [redirect, setRedirect] = useState("/dashboard");
+
+    useEffect(() => {
+        authAPI.getProviders().then((res) => setProviders(res.data)).catch(() => setProviders([]));
+
+        // An OAuth sign-in that still needs a code lands here from the callback page
+        const pending = sessionStorage.getItem("twoFactorChallenge");
+        if (pending) {
+            setChallenge(pending);
+            setRedirect(sessionStorage.getItem("twoFactorRedirect") || "/dashboard");
+            sessionStorage.removeItem("twoFactorChallenge");
+            sessionStorage.removeItem("twoFactorRedirect");
+        }
+    }, []);
 
     const handleSubmit = async (e: React.FormEvent) => {
         e.preventDefault();
@@ -19,10 +37,20 @@ export default function LoginPage() {
         setLoading(true);
 
         try {
+            if (challenge) {
+                const response = await authAPI.loginTwoFactor(challenge, code);
+                saveSession(response.data);
+                router.push(redirect);
+                return;
+            }
+
             const response = await authAPI.login(formData);
-            localStorage.setItem("token", response.data.token);
-            localStorage.setItem("user", JSON.stringify(response.data.user));
-            router.push("/dashboard");
+            if (response.data.twoFactorRequired) {
+                setChallenge(response.data.challengeToken);
+                return;
+            }
+            saveSession(response.data);
+            router.push(redirect);
         } catch (err: unknown) {
             const error = err as { response?: { data?: { error?: string } } };
             setError(error.response?.data?.error || "Login failed. Please try again.");
@@ -51,6 +79,23 @@ export default function LoginPage() {
                             </div>
                         )}
 
+                        {challenge ? (
+                            <div>
+                                <label className="block text-sm font-medium mb-2">Authentication code</label>
+                                <input
+                                    type="text"
+                                    inputMode="numeric"
+                                    autoComplete="one-time-code"
+                                    value={code}
+                                    onChange={(e) => setCode(e.target.value)}
+                                    className="input-field"
+                                    placeholder="123456 or a recovery code"
+                                    autoFocus
+                                    required
+                                />
+                            </div>
+                        ) : (
+                        <>
                         <div>
                             <label className="block text-sm font-medium mb-2">Username</label>
                             <input
@@ -74,16 +119,28 @@ export default function LoginPage() {
                                 required
                             />
                         </div>
+                        </>
+                        )}
 
                         <button
                             type="submit"
                             disabled={loading}
                             className="btn-primary w-full disabled:opacity-50"
                         >
-                            {loading ? "Logging in..." : "Login"}
+                            {loading ? "Logging in..." : challenge ? "Verify" : "Login"}
                         </button>
                     </form>
 
+                    {providers.length > 0 && (
+                        <div className="mt-6 space-y-3">
+                            {providers.map((provider) => (
+                                <a key={provider} href={oauthStartURL(provider)} className="btn-secondary w-full block text-center capitalize">
+                                    Continue with {provider === "oidc" ? "SSO" : provider}
+                                </a>
+                            ))}
+                        </div>
+                    )}
+
                     <p className="text-center mt-6 text-[var(--text-secondary)] text-sm">
                         Don&apos;t have an account?{" "}
                         <Link href="/auth/register" className="text-[var(--accent-cyan)] hover:underline">
@@ -2,7 +2,7 @@
 import Link from "next/link";
 import { useState } from "react";
 import { useRouter } from "next/navigation";
-import { authAPI } from "@/lib/api";
+import { authAPI, saveSession } from "@/lib/api";
 
 export default function RegisterPage() {
     const router = useRouter();
@@ -37,8 +37,7 @@ export default function RegisterPage() {
                 password: formData.password,
                 apiKey: formData.apiKey,
             });
-            localStorage.setItem("token", response.data.token);
-            localStorage.setItem("user", JSON.stringify(response.data.user));
+            saveSession(response.data);
             router.push("/dashboard");
         } catch (err: unknown) {
             const error = err as { response?: { data?: { error?: string } } };
@@ -0,0 +1,115 @@
+"use client";
+import Link from "next/link";
+import { useEffect, useState } from "react";
+import { authAPI } from "@/lib/api";
+
+// Landing page for the link in password reset emails
+export default function ResetPasswordPage() {
+    const [token, setToken] = useState("");
+    const [password, setPassword] = useState("");
+    const [confirmPassword, setConfirmPassword] = useState("");
+    const [error, setError] = useState("");
+    const [done, setDone] = useState(false);
+    const [loading, setLoading] = useState(false);
+
+    useEffect(() => {
+        const linkToken = new URLSearchParams(window.location.search).get("token") || "";
+        window.history.replaceState(null, "", window.location.pathname);
+        setToken(linkToken);
+        if (!linkToken) setError("This reset link is invalid. Request a new one from the login page.");
+    }, []);
+
+    const handleSubmit = async (e: React.FormEvent) => {
+        e.preventDefault();
+        setError("");
+
+        if (password !== confirmPassword) {
+            setError("Passwords do not match");
+            return;
+        }
+        if (password.length < 6) {
+            setError("Password must be at least 6 characters");
+            return;
+        }
+
+        setLoading(true);
+        try {
+            await authAPI.resetPassword(token, password);
+            setDone(true);
+        } catch (err: unknown) {
+            const error = err as { response?: { data?: { error?: string } } };
+            setError(error.response?.data?.error || "Failed to reset password. Please try again.");
+        } finally {
+            setLoading(false);
+        }
+    };
+
+    return (
+        <div className="min-h-screen bg-[var(--bg-primary)] flex items-center justify-center px-4 py-8">
+            <div className="w-full max-w-sm">
+                <div className="text-center mb-8">
+                    <Link href="/" className="text-2xl font-bold gradient-text">
+                        Woohoo DSA
+                    </Link>
+                    <p className="text-[var(--text-secondary)] mt-2 text-sm">
+                        Choose a new password
+                    </p>
+                </div>
+
+                <div className="glass-card p-6 sm:p-8">
+                    {done ? (
+                        <div className="text-center space-y-6">
+                            <p className="text-[var(--text-secondary)] text-sm">
+                                Your password has been reset. You have been signed out everywhere else.
+                            </p>
+                            <Link href="/auth/login" className="btn-primary w-full inline-block">
+                                Login
+                            </Link>
+                        </div>
+                    ) : (
+                        <form onSubmit={handleSubmit} className="space-y-4">
+                            {error && (
+                                <div className="bg-red-500/10 border border-red-500/30 text-red-400 px-4 py-3 rounded-lg text-sm">
+                                    {error}
+                                </div>
+                            )}
+
+                            <div>
+                                <label className="block text-sm font-medium mb-2">New Password</label>
+                                <input
+                                    type="password"
+                                    value={password}
+                                    onChange={(e) => setPassword(e.target.value)}
+                                    className="input-field"
+                                    placeholder="••••••••"
+                                    required
+                                    minLength={6}
+                                />
+                            </div>
+
+                            <div>
+                                <label className="block text-sm font-medium mb-2">Confirm Password</label>
+                                <input
+                                    type="password"
+                                    value={confirmPassword}
+                                    onChange={(e) => setConfirmPassword(e.target.value)}
+                                    className="input-field"
+                                    placeholder="••••••••"
+                                    required
+                                />
+                            </div>
+
+                            <button
+                                type="submit"
+                                disabled={loading || !token}
+                                className="btn-primary w-full disabled:opacity-50"
+                            >
+                                {loading ? "Resetting..." : "Reset Password"}
+                            </button>
+                        </form>
+                    )}
+                </div>
+            </div>
+        </div>
+    );
+}
@@ -0,0 +1,61 @@
+"use client";
+import Link from "next/link";
+import { useEffect, useRef, useState } from "react";
+import { authAPI } from "@/lib/api";
+
+// Landing page for the link in email verification messages
+export default function VerifyEmailPage() {
+    const [status, setStatus] = useState<"verifying" | "verified" | "failed">("verifying");
+    const [error, setError] = useState("");
+    // The token is single use; don't spend it twice if the effect re-runs
+    const started = useRef(false);
+
+    useEffect(() => {
+        if (started.current) return;
+        started.current = true;
+
+        const token = new URLSearchParams(window.location.search).get("token");
+        window.history.replaceState(null, "", window.location.pathname);
+        if (!token) {
+            setError("This verification link is invalid.");
+            setStatus("failed");
+            return;
+        }
+        authAPI
+            .verifyEmail(token)
+            .then(() => setStatus("verified"))
+            .catch((err: unknown) => {
+                const error = err as { response?: { data?: { error?: string } } };
+                setError(error.response?.data?.error || "Failed to verify email. Please try again.");
+                setStatus("failed");
+            });
+    }, []);
+
+    return (
+        <div className="min-h-screen bg-[var(--bg-primary)] flex items-center justify-center px-4 py-8">
+            <div className="glass-card p-6 sm:p-8 w-full max-w-sm text-center">
+                {status === "verifying" && (
+                    <p className="text-[var(--text-secondary)] text-sm">Verifying your email...</p>
+                )}
+                {status === "verified" && (
+                    <>
+                        <p className="text-[var(--text-secondary)] text-sm mb-6">Your email address is verified.</p>
+                        <Link href="/dashboard" className="text-[var(--accent-cyan)] hover:underline text-sm">
+                            Go to dashboard
+                        </Link>
+                    </>
+                )}
+                {status === "failed" && (
+                    <>
+                        <div className="bg-red-500/10 border border-red-500/30 text-red-400 px-4 py-3 rounded-lg text-sm mb-6">
+                            {error}
+                        </div>
+                        <Link href="/dashboard" className="text-[var(--accent-cyan)] hover:underline text-sm">
+                            Go to dashboard
+                        </Link>
+                    </>
+                )}
+            </div>
+        </div>
+    );
+}
@@ -44,13 +44,13 @@ export default function DashboardPage() {
     const fetchData = async () => {
         try {
             const [problemsRes, progressRes, profileRes, topicsRes] = await Promise.all([
-                problemAPI.getAll(),
+                problemAPI.getAllPages(),
                 progressAPI.getAll(),
                 authAPI.getProfile(),
                 problemAPI.getTopics(),
             ]);
 
-            setProblems(problemsRes.data);
+            setProblems(problemsRes as Problem[]);
             setUser(profileRes.data);
             const topicsList = topicsRes.data || [];
             setTopics(topicsList);
@@ -60,7 +60,7 @@ export default function DashboardPage() {
             }
 
             const pMap: Record<string, string> = {};
-            progressRes.data.forEach((p: Progress) => {
+            (progressRes as Progress[]).forEach((p) => {
                 pMap[p.problemId] = p.status;
             });
             setProgressMap(pMap);
@@ -71,9 +71,8 @@ export default function DashboardPage() {
         }
     };
 
-    const handleLogout = () => {
-        localStorage.removeItem("token");
-        localStorage.removeItem("user");
+    const handleLogout = async () => {
+        await authAPI.logout();
         router.push("/");
     };
 
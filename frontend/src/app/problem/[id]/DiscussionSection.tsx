@@ -27,7 +27,7 @@ export default function DiscussionSection({ problemId }: DiscussionSectionProps)
     const fetchComments = useCallback(async () => {
         try {
             const res = await commentAPI.getAll(problemId);
-            setComments(res.data);
+            setComments(res.data.items);
         } catch (error) {
             console.error("Failed to fetch comments:", error);
         } finally {
@@ -20,11 +20,25 @@ interface Problem {
     difficulty: string;
     topic: string;
     description: string;
+    descriptionHtml?: string;
     starterCode: string;
     testCases: TestCase[];
-    hintBrute: string;
-    hintOptimized: string;
-    bestSolution: string;
+    hintBrute?: string;
+    hintOptimized?: string;
+    editorial?: Editorial;
+}
+
+interface Approach {
+    name: string;
+    title: string;
+    explanation: string;
+    timeComplexity: string;
+    spaceComplexity: string;
+    code: Record<string, string>;
+}
+
+interface Editorial {
+    approaches: Approach[];
 }
 
 interface Progress {
@@ -32,6 +46,7 @@ interface Progress {
     attempts: number;
     successfulSubmissions: number;
     notes: string;
+    revealLevel: number;
 }
 
 export default function ProblemPage() {
@@ -46,11 +61,24 @@ export default function ProblemPage() {
     const [activeTab, setActiveTab] = useState<"description" | "hints" | "solution" | "discussion">("description");
     const [mobileView, setMobileView] = useState<"problem" | "editor">("problem");
     const [hintLevel, setHintLevel] = useState(0);
+    const [revealError, setRevealError] = useState("");
     const [verdict, setVerdict] = useState<{ verdict: string; feedback: string; passed: boolean } | null>(null);
     const [submitting, setSubmitting] = useState(false);
     const [saving, setSaving] = useState(false);
     const [loading, setLoading] = useState(true);
 
+    const reveal = async (level: "hint1" | "hint2" | "solution") => {
+        try {
+            const res = await problemAPI.reveal(problemId, level);
+            setProblem((p) => (p ? { ...p, ...res.data } : p));
+            setHintLevel(Math.min(res.data.revealLevel, 2));
+            setRevealError("");
+        } catch (err: unknown) {
+            const error = err as { response?: { data?: { error?: string } } };
+            setRevealError(error.response?.data?.error || "Failed to reveal");
+        }
+    };
+
     const fetchProblem = useCallback(async () => {
         if (!problemId) return;
         try {
@@ -65,6 +93,14 @@ export default function ProblemPage() {
 
             const savedCode = localStorage.getItem(`code_${problemId}`);
             setCode(savedCode || problemRes.data.starterCode);
+
+            // Restore whatever was unlocked in a previous visit
+            const level = progressRes.data.revealLevel || 0;
+            if (level > 0) {
+                const revealRes = await problemAPI.reveal(problemId, (["hint1", "hint2", "solution"] as const)[level - 1]);
+                setProblem({ ...problemRes.data, ...revealRes.data });
+                setHintLevel(Math.min(level, 2));
+            }
         } catch (error) {
             console.error("Failed to fetch problem:", error);
         } finally {
@@ -243,9 +279,17 @@ export default function ProblemPage() {
                     <div className="flex-1 overflow-y-auto p-6">
                         {activeTab === "description" && (
                             <div>
-                                <pre className="whitespace-pre-wrap text-base text-[var(--text-secondary)] font-sans leading-relaxed mb-8">
-                                    {problem.description}
-                                </pre>
+                                {problem.descriptionHtml ? (
+                                    <div
+                                        className="statement text-base text-[var(--text-secondary)] leading-relaxed mb-8"
+                                        // Sanitized on the server
+                                        dangerouslySetInnerHTML={{ __html: problem.descriptionHtml }}
+                                    />
+                                ) : (
+                                    <pre className="whitespace-pre-wrap text-base text-[var(--text-secondary)] font-sans leading-relaxed mb-8">
+                                        {problem.description}
+                                    </pre>
+                                )}
 
                                 <h3 className="font-semibold mb-4">Test Cases</h3>
                                 <div className="space-y-3">
@@ -273,7 +317,7 @@ export default function ProblemPage() {
 
                                 <div className="bg-[var(--bg-tertiary)] border border-[var(--glass-border)] rounded-md overflow-hidden">
                                     <button
-                                        onClick={() => setHintLevel((h) => (h >= 1 ? h : 1))}
+                                        onClick={() => reveal("hint1")}
                                         className="w-full flex items-center justify-between p-4 hover:bg-[var(--bg-secondary)] transition-colors"
                                     >
                                         <span className="font-medium">Hint 1: Brute Force Approach</span>
@@ -288,7 +332,7 @@ export default function ProblemPage() {
 
                                 <div className="bg-[var(--bg-tertiary)] border border-[var(--glass-border)] rounded-md overflow-hidden">
                                     <button
-                                        onClick={() => hintLevel >= 1 && setHintLevel((h) => (h >= 2 ? h : 2))}
+                                        onClick={() => hintLevel >= 1 && reveal("hint2")}
                                         className={`w-full flex items-center justify-between p-4 transition-colors ${hintLevel < 1 ? "opacity-50 cursor-not-allowed" : "hover:bg-[var(--bg-secondary)]"}`}
                                     >
                                         <span className="font-medium">Hint 2: Optimized Approach</span>
@@ -310,21 +354,50 @@ export default function ProblemPage() {
                                         Only view this after you&apos;ve tried solving it yourself!
                                     </p>
                                 </div>
-                                <div className="border border-[var(--glass-border)] rounded-md overflow-hidden">
-                                    <Editor
-                                        height="400px"
-                                        defaultLanguage="cpp"
-                                        value={problem.bestSolution}
-                                        theme="vs-dark"
-                                        options={{
-                                            readOnly: true,
-                                            minimap: { enabled: false },
-                                            scrollBeyondLastLine: false,
-                                            fontSize: 14,
-                                            fontFamily: "'JetBrains Mono', monospace",
-                                        }}
-                                    />
-                                </div>
+                                {!problem.editorial ? (
+                                    <div className="space-y-3">
+                                        <button onClick={() => reveal("solution")} className="btn-secondary py-2 px-4 text-sm">
+                                            Reveal solution
+                                        </button>
+                                        {revealError && <p className="text-[var(--accent-orange)] text-sm">{revealError}</p>}
+                                    </div>
+                                ) : (
+                                    <div className="space-y-6">
+                                        {problem.editorial.approaches.map((approach) => {
+                                            const language = approach.code.cpp !== undefined ? "cpp" : Object.keys(approach.code)[0];
+                                            return (
+                                                <div key={approach.name} className="space-y-3">
+                                                    <h3 className="font-medium">{approach.title || approach.name}</h3>
+                                                    {approach.explanation && (
+                                                        <p className="text-sm text-[var(--text-secondary)] whitespace-pre-wrap">{approach.explanation}</p>
+                                                    )}
+                                                    {(approach.timeComplexity || approach.spaceComplexity) && (
+                                                        <p className="text-xs text-[var(--text-muted)]">
+                                                            Time: {approach.timeComplexity || "—"} · Space: {approach.spaceComplexity || "—"}
+                                                        </p>
+                                                    )}
+                                                    {language && (
+                                                        <div className="border border-[var(--glass-border)] rounded-md overflow-hidden">
+                                                            <Editor
+                                                                height="400px"
+                                                                defaultLanguage={language}
+                                                                value={approach.code[language]}
+                                                                theme="vs-dark"
+                                                                options={{
+                                                                    readOnly: true,
+                                                                    minimap: { enabled: false },
+                                                                    scrollBeyondLastLine: false,
+                                                                    fontSize: 14,
+                                                                    fontFamily: "'JetBrains Mono', monospace",
+                                                                }}
+                                                            />
+                                                        </div>
+                                                    )}
+                                                </div>
+                                            );
+                                        })}
+                                    </div>
+                                )}
                             </div>
                         )}
 
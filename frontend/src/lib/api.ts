@@ -28,46 +28,159 @@ api.interceptors.request.use((config) => {
   return config;
 });
 
+const clearSession = () => {
+  localStorage.removeItem('token');
+  localStorage.removeItem('refreshToken');
+  localStorage.removeItem('user');
+};
+
+// Stores the token pair returned by login, register and refresh
+export const saveSession = (data: { token: string; refreshToken: string; user?: unknown }) => {
+  localStorage.setItem('token', data.token);
+  localStorage.setItem('refreshToken', data.refreshToken);
+  if (data.user) localStorage.setItem('user', JSON.stringify(data.user));
+};
+
+// Access tokens are short-lived; concurrent 401s share one refresh call so the
+// refresh token is only rotated once
+let refreshing: Promise<string> | null = null;
+const refreshAccessToken = () => {
+  if (!refreshing) {
+    const refreshToken = localStorage.getItem('refreshToken');
+    refreshing = (refreshToken
+      ? axios.post(`${API_BASE}/auth/refresh`, { refreshToken }).then((res) => {
+          saveSession(res.data);
+          return res.data.token as string;
+        })
+      : Promise.reject(new Error('No refresh token'))
+    ).finally(() => {
+      refreshing = null;
+    });
+  }
+  return refreshing;
+};
+
 // Handle auth errors
 api.interceptors.response.use(
   (response) => response,
-  (error) => {
-    if (error.response?.status === 401) {
-      if (typeof window !== 'undefined') {
-        localStorage.removeItem('token');
-        localStorage.removeItem('user');
-        window.location.href = '/auth/login';
+  async (error) => {
+    const original = error.config;
+    if (error.response?.status === 401 && typeof window !== 'undefined') {
+      if (original && !original._retried && !original.url?.startsWith('/auth/')) {
+        original._retried = true;
+        try {
+          const token = await refreshAccessToken();
+          original.headers.Authorization = `Bearer ${token}`;
+          return api(original);
+        } catch {
+          // Fall through to signing out
+        }
       }
+      clearSession();
+      window.location.href = '/auth/login';
     }
     return Promise.reject(error);
   }
 );
 
+// Walks a paginated list endpoint via its `next` token and returns every item
+export const fetchAll = async <T,>(path: string, params: Record<string, string> = {}): Promise<T[]> => {
+  const items: T[] = [];
+  let next = '';
+  do {
+    const query = new URLSearchParams({ ...params, limit: '100' });
+    if (next) query.set('next', next);
+    const res = await api.get(`${path}?${query.toString()}`);
+    items.push(...res.data.items);
+    next = res.data.next || '';
+  } while (next);
+  return items;
+};
+
+// Browser navigation target that starts an OAuth sign-in
+export const oauthStartURL = (provider: string, redirect = '/dashboard') =>
+  `${API_BASE}/auth/oauth/${provider}/start?redirect=${encodeURIComponent(redirect)}`;
+
 // Auth APIs
 export const authAPI = {
-  register: (data: { username: string; password: string; apiKey?: string }) =>
+  register: (data: { username: string; password: string; email?: string; apiKey?: string }) =>
     api.post('/auth/register', data),
   login: (data: { username: string; password: string }) =>
     api.post('/auth/login', data),
+  logout: async () => {
+    const refreshToken = localStorage.getItem('refreshToken');
+    clearSession();
+    if (refreshToken) await api.post('/auth/logout', { refreshToken }).catch(() => undefined);
+  },
+  updateEmail: (email: string) => api.put('/email', { email }),
+  verifyEmail: (token: string) => api.post('/auth/verify-email', { token }),
+  changePassword: (oldPassword: string, newPassword: string) =>
+    api.put('/password', { oldPassword, newPassword }),
+  forgotPassword: (email: string) => api.post('/auth/forgot-password', { email }),
+  resetPassword: (token: string, newPassword: string) =>
+    api.post('/auth/reset-password', { token, newPassword }),
+  loginTwoFactor: (challengeToken: string, code: string) =>
+    api.post('/auth/2fa', { challengeToken, code }),
+  enrollTwoFactor: () => api.post('/2fa/enroll'),
+  confirmTwoFactor: (code: string) => api.post('/2fa/verify', { code }),
+  disableTwoFactor: (code: string) => api.post('/2fa/disable', { code }),
+  regenerateRecoveryCodes: (code: string) => api.post('/2fa/recovery-codes', { code }),
+  getProviders: () => api.get('/auth/providers'),
+  // Credentialed so the browser keeps the state cookie the callback checks
+  linkProvider: (provider: string) =>
+    api.post(`/auth/oauth/${provider}/link`, undefined, { withCredentials: true }),
+  unlinkProvider: (provider: string) => api.delete(`/auth/oauth/${provider}`),
+  getAccessTokens: () => api.get('/tokens'),
+  createAccessToken: (data: { name: string; scopes: string[]; expiresInDays?: number }) =>
+    api.post('/tokens', data),
+  revokeAccessToken: (id: string) => api.delete(`/tokens/${id}`),
+  exportAccount: () => api.get('/account/export', { responseType: 'blob' }),
+  deleteAccount: (data: { password?: string; confirm?: string; code?: string }) =>
+    api.delete('/account', { data }),
+  getSessions: () => api.get('/sessions'),
+  revokeSession: (id: string) => api.delete(`/sessions/${id}`),
   getProfile: () => api.get('/profile'),
+  updateProfile: (data: {
+    displayName?: string;
+    bio?: string;
+    avatarUrl?: string;
+    links?: { label: string; url: string }[];
+    visibility?: 'public' | 'private';
+  }) => api.put('/profile', data),
+  // The access token carries the username, so the new one replaces it
+  changeUsername: async (username: string) => {
+    const res = await api.put('/profile/username', { username });
+    localStorage.setItem('token', res.data.token);
+    const user = localStorage.getItem('user');
+    if (user) localStorage.setItem('user', JSON.stringify({ ...JSON.parse(user), username: res.data.username }));
+    return res;
+  },
+  getPublicProfile: (username: string) => api.get(`/users/${encodeURIComponent(username)}`),
   updateApiKey: (apiKey: string) => api.put('/apikey', { apiKey }),
 };
 
 // Problem APIs
 export const problemAPI = {
-  getAll: (topic?: string, difficulty?: string) => {
+  getAll: (topic?: string, difficulty?: string, filters?: { q?: string; tags?: string[]; companies?: string[]; match?: 'all' | 'any' }) => {
     const params = new URLSearchParams();
     if (topic) params.append('topic', topic);
     if (difficulty) params.append('difficulty', difficulty);
+    if (filters?.q) params.append('q', filters.q);
+    if (filters?.tags?.length) params.append('tags', filters.tags.join(','));
+    if (filters?.companies?.length) params.append('companies', filters.companies.join(','));
+    if (filters?.match) params.append('match', filters.match);
     return api.get(`/problems?${params.toString()}`);
   },
   getById: (id: string) => api.get(`/problems/${id}`),
+  reveal: (id: string, level: 'hint1' | 'hint2' | 'solution') =>
+    api.post(`/problems/${id}/reveal`, { level }),
+  getAllPages: () => fetchAll('/problems'),
   getTopics: () => api.get('/topics'),
 };
 
 // Progress APIs
 export const progressAPI = {
-  getAll: () => api.get('/progress'),
+  getAll: () => fetchAll('/progress'),
   getByProblem: (problemId: string) => api.get(`/progress/${problemId}`),
   updateNotes: (problemId: string, notes: string) =>
     api.put(`/progress/${problemId}/notes`, { notes }),